@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newAnnotateCmd builds the "annotate" subcommand, which answers "where
+// does this rendered resource come from?": given a component path and a
+// resource kind and name, it traces the kustomize build backwards and
+// lists the source files, patches and overlay layers that contribute to
+// it.
+func newAnnotateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "annotate <component-path> <kind> <name>",
+		Short: "Trace a rendered resource back to its source files",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			root, err := gitClient.TopLevel(ctx)
+			if err != nil {
+				return err
+			}
+			path, kind, name := args[0], args[1], args[2]
+			entries := detect.Trace(root, path, kind, name)
+			if len(entries) == 0 {
+				return fmt.Errorf("no source of %s/%s found under %s", kind, name, path)
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "%s/%s in %s is assembled from:\n", kind, name, path)
+			for _, entry := range entries {
+				fmt.Fprintf(out, "  %-8s %s\n", entry.Role, entry.File)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/report"
+)
+
+// newCheckApprovalsCmd builds the "check-approvals" subcommand, which turns
+// the approval checklist in the render-diff comment into a commit status:
+// pending while boxes remain unticked, success once reviewers have checked
+// them all.
+func newCheckApprovalsCmd() *cobra.Command {
+	var (
+		githubRepo string
+		prNumber   int
+		sha        string
+	)
+	cmd := &cobra.Command{
+		Use:   "check-approvals",
+		Short: "Set a commit status from the approval checklist state",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if githubRepo == "" || prNumber == 0 || sha == "" {
+				return fmt.Errorf("check-approvals requires --github-repo, --pr-number and --sha")
+			}
+			client, err := ghclientFromEnv(cmd.Context(), githubRepo)
+			if err != nil {
+				return err
+			}
+			complete, err := client.ChecklistComplete(cmd.Context(), prNumber, report.CommentMarker)
+			if err != nil {
+				return err
+			}
+			state, description := "pending", "approval checklist has unticked environments"
+			if complete {
+				state, description = "success", "all affected environments approved"
+			}
+			return client.SetStatus(cmd.Context(), sha, state, "render-diff/approvals", description)
+		},
+	}
+	cmd.Flags().StringVar(&githubRepo, "github-repo", "", "GitHub repository (owner/repo)")
+	cmd.Flags().IntVar(&prNumber, "pr-number", 0, "pull request number")
+	cmd.Flags().StringVar(&sha, "sha", "", "commit SHA to set the status on")
+	return cmd
+}
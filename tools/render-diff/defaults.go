@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// applyEnvOverrides sets flags from RENDER_DIFF_* environment variables:
+// --max-diff-lines reads RENDER_DIFF_MAX_DIFF_LINES, and so on for every
+// flag. Precedence, highest first: command line, environment,
+// .render-diff.yaml defaults, built-in defaults.
+func applyEnvOverrides(cmd *cobra.Command) error {
+	var firstErr error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			return
+		}
+		name := "RENDER_DIFF_" + strings.ToUpper(strings.ReplaceAll(flag.Name, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		// Set through the flag set so the flag counts as changed and the
+		// config-file defaults no longer apply to it.
+		if err := cmd.Flags().Set(flag.Name, value); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("applying %s: %w", name, err)
+		}
+	})
+	return firstErr
+}
+
+// applyConfigDefaults fills flags the user did not pass from the defaults:
+// section of .render-diff.yaml. Explicit flags always win. Defaults naming
+// flags the invoked command does not define are skipped, so root-command
+// defaults do not break subcommands.
+func applyConfigDefaults(cmd *cobra.Command) error {
+	root, err := git.New(".").TopLevel(cmd.Context())
+	if err != nil {
+		// Outside a checkout there is no configuration to load; commands
+		// that need a repository report that themselves.
+		return nil
+	}
+	cfg, err := config.Load(root)
+	if err != nil {
+		return err
+	}
+	for name, value := range cfg.Defaults {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return fmt.Errorf("applying %s default for --%s: %w", config.FileName, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/cache"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newDoctorCmd builds the "doctor" subcommand, which checks the local
+// environment for everything render-diff may need — git, kustomize, helm,
+// kubectl, GitHub credentials, reachability of remote bases — and prints
+// an actionable fix for each problem.
+func newDoctorCmd() *cobra.Command {
+	var overlaysDirs []string
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the local environment and suggest fixes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			out := cmd.OutOrStdout()
+			failures := 0
+			report := func(status, subject, detail string) {
+				fmt.Fprintf(out, "%-4s %-18s %s\n", status, subject, detail)
+				if status == "FAIL" {
+					failures++
+				}
+			}
+
+			if version, err := commandOutput(ctx, "git", "--version"); err != nil {
+				report("FAIL", "git", "not found on PATH — install git 2.25 or newer")
+			} else if major, minor := parseGitVersion(version); major < 2 || (major == 2 && minor < 25) {
+				report("WARN", "git", version+" — upgrade to 2.25+ for sparse worktree support")
+			} else {
+				report("OK", "git", version)
+			}
+
+			gitClient := git.New(".")
+			root, err := gitClient.TopLevel(ctx)
+			if err != nil {
+				report("FAIL", "repository", "not inside a git checkout — run from the infra-deployments repo")
+			} else {
+				report("OK", "repository", root)
+			}
+
+			if version, err := commandOutput(ctx, "kustomize", "version"); err != nil {
+				report("OK", "kustomize", "not on PATH — the built-in renderer will be used (install it or pass --kustomize-version to pin)")
+			} else {
+				report("OK", "kustomize", version)
+			}
+			if version, err := commandOutput(ctx, "helm", "version", "--short"); err != nil {
+				report("WARN", "helm", "not on PATH — --enable-helm builds will fail")
+			} else {
+				report("OK", "helm", version)
+			}
+			if _, err := exec.LookPath("kubectl"); err != nil {
+				report("WARN", "kubectl", "not on PATH — --against-cluster and drift need it")
+			} else {
+				report("OK", "kubectl", "found")
+			}
+
+			switch {
+			case os.Getenv("GITHUB_TOKEN") != "":
+				report("OK", "github auth", "GITHUB_TOKEN is set")
+			case os.Getenv("GITHUB_APP_ID") != "":
+				report("OK", "github auth", "GitHub App credentials are set")
+			default:
+				report("WARN", "github auth", "neither GITHUB_TOKEN nor GITHUB_APP_ID set — comment posting will fail")
+			}
+
+			if root != "" {
+				paths, err := discoverAll(root, overlaysDirs)
+				if err == nil {
+					detector := detect.New(paths)
+					if err := detector.ScanKustomizeRefs(root); err == nil {
+						for _, ref := range detector.RemoteRefs() {
+							url := cache.RepoURL(ref)
+							if url == "" {
+								continue
+							}
+							probeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+							err := exec.CommandContext(probeCtx, "git", "ls-remote", "--exit-code", url, "HEAD").Run()
+							cancel()
+							if err != nil {
+								report("FAIL", "remote base", url+" unreachable — check network/VPN or use --cache-remote-bases on a machine that can")
+							} else {
+								report("OK", "remote base", url)
+							}
+						}
+					}
+				}
+			}
+
+			if failures > 0 {
+				return fmt.Errorf("%d check(s) failed", failures)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	return cmd
+}
+
+// commandOutput runs a command and returns its first line of output.
+func commandOutput(ctx context.Context, name string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return line, nil
+}
+
+// parseGitVersion extracts major and minor from "git version X.Y.Z".
+func parseGitVersion(version string) (major, minor int) {
+	fields := strings.Fields(version)
+	numbers := fields[len(fields)-1]
+	parts := strings.Split(numbers, ".")
+	if len(parts) >= 2 {
+		major, _ = strconv.Atoi(parts[0])
+		minor, _ = strconv.Atoi(parts[1])
+	}
+	return major, minor
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/cluster"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/diffutil"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/report"
+)
+
+// newDriftCmd builds the "drift" subcommand: render a ref (main, by
+// default) and compare every component against live cluster state,
+// reporting where the cluster has drifted from what the repo declares.
+// Meant for scheduled audit runs rather than PRs.
+func newDriftCmd() *cobra.Command {
+	var (
+		ref          string
+		kubeconfig   string
+		overlaysDirs []string
+		include      []string
+		exclude      []string
+		jsonOut      string
+	)
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report drift between rendered manifests and live cluster state",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			sha, err := gitClient.ResolveRef(ctx, ref)
+			if err != nil {
+				return err
+			}
+			root, cleanup, err := tempWorktree(ctx, gitClient, sha)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			paths, err := discoverAll(root, overlaysDirs)
+			if err != nil {
+				return err
+			}
+			paths = detect.Filter(paths, include, exclude)
+			cfg, err := config.Load(root)
+			if err != nil {
+				return err
+			}
+			vars, err := substitutionVars(root, cfg)
+			if err != nil {
+				return err
+			}
+			jobs := make([]engine.Job, 0, len(paths))
+			for _, p := range paths {
+				jobs = append(jobs, engine.Job{Path: p})
+			}
+			eng := &engine.Engine{Builder: &engine.KrustyBuilder{}, Vars: vars, KeepRenders: true}
+			result, err := eng.Run(ctx, root, root, jobs)
+			if err != nil {
+				return err
+			}
+			result.BaseRef, result.BaseSHA, result.HeadSHA = "live cluster", sha, sha
+			drifted := 0
+			for i := range result.Components {
+				c := &result.Components[i]
+				if c.Error != "" {
+					continue
+				}
+				live, err := cluster.LiveRender(ctx, kubeconfig, c.HeadRender)
+				if err != nil {
+					c.Error = err.Error()
+					continue
+				}
+				diff, err := diffutil.Unified(c.Path.Path, live, c.HeadRender)
+				if err != nil {
+					return err
+				}
+				c.Diff = diff
+				c.Added, c.Removed = diffutil.Stats(diff)
+				if diff != "" {
+					drifted++
+				}
+			}
+			if jsonOut != "" {
+				if err := report.WriteJSON(result, jsonOut); err != nil {
+					return err
+				}
+			}
+			fmt.Fprint(os.Stdout, report.Text(result))
+			if drifted > 0 {
+				return fmt.Errorf("%d component(s) have drifted from %s", drifted, ref)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&ref, "ref", "origin/main", "git ref representing the declared state")
+	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "kubeconfig for the audited cluster (default: ambient)")
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "only audit component paths matching these globs")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "skip component paths matching these globs")
+	cmd.Flags().StringVar(&jsonOut, "json", "", "also write the drift report as JSON to this file")
+	return cmd
+}
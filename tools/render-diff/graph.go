@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newGraphCmd builds the "component-graph" subcommand, which emits the
+// dependency graph between rendered component paths and the shared
+// directories their kustomizations pull in — the blast radius of a base
+// change, as a picture.
+func newGraphCmd() *cobra.Command {
+	var (
+		overlaysDirs []string
+		format       string
+		component    string
+	)
+	cmd := &cobra.Command{
+		Use:   "component-graph",
+		Short: "Emit the component dependency graph in DOT or Mermaid format",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			repoRoot, err := gitClient.TopLevel(ctx)
+			if err != nil {
+				return err
+			}
+			paths, err := discoverAll(repoRoot, overlaysDirs)
+			if err != nil {
+				return err
+			}
+			detector := detect.New(paths)
+			if err := detector.ScanKustomizeRefs(repoRoot); err != nil {
+				return err
+			}
+			type edge struct{ from, to string }
+			var edges []edge
+			seen := map[edge]bool{}
+			for _, p := range paths {
+				if component != "" && p.Component != component && p.Path != component {
+					continue
+				}
+				for _, dep := range detector.Deps(p.Path) {
+					e := edge{p.Path, dep}
+					if !seen[e] {
+						seen[e] = true
+						edges = append(edges, e)
+					}
+				}
+			}
+			sort.Slice(edges, func(i, j int) bool {
+				if edges[i].from != edges[j].from {
+					return edges[i].from < edges[j].from
+				}
+				return edges[i].to < edges[j].to
+			})
+			out := cmd.OutOrStdout()
+			switch format {
+			case "dot":
+				fmt.Fprintln(out, "digraph components {")
+				fmt.Fprintln(out, "  rankdir=LR;")
+				for _, e := range edges {
+					fmt.Fprintf(out, "  %q -> %q;\n", e.from, e.to)
+				}
+				fmt.Fprintln(out, "}")
+			case "mermaid":
+				fmt.Fprintln(out, "graph LR")
+				for _, e := range edges {
+					fmt.Fprintf(out, "  %s[\"%s\"] --> %s[\"%s\"]\n", mermaidID(e.from), e.from, mermaidID(e.to), e.to)
+				}
+			default:
+				return fmt.Errorf("unknown format %q (want dot or mermaid)", format)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	cmd.Flags().StringVar(&format, "format", "dot", "output format: dot or mermaid")
+	cmd.Flags().StringVar(&component, "component", "", "limit the graph to one component directory or path")
+	return cmd
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// mermaidID derives a node identifier Mermaid accepts from a path.
+func mermaidID(path string) string {
+	return strings.Trim(mermaidUnsafe.ReplaceAllString(path, "_"), "_")
+}
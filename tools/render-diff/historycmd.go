@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newHistoryCmd builds the "history" subcommand: the per-commit render
+// walk over an arbitrary commit range rather than a PR, producing a
+// timeline of which commits changed which components' renders — for
+// release notes and postmortem archaeology.
+func newHistoryCmd() *cobra.Command {
+	var (
+		from         string
+		to           string
+		overlaysDirs []string
+		include      []string
+		exclude      []string
+		outputFile   string
+		buildTimeout time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show which commits in a range changed which renders",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			fromSHA, err := gitClient.ResolveRef(ctx, from)
+			if err != nil {
+				return err
+			}
+			toSHA, err := gitClient.ResolveRef(ctx, to)
+			if err != nil {
+				return err
+			}
+			// The per-commit walker only needs the subset of root options
+			// that shape detection and builds.
+			opts := &options{
+				overlaysDirs: overlaysDirs,
+				include:      include,
+				exclude:      exclude,
+				outputFile:   outputFile,
+				buildTimeout: buildTimeout,
+			}
+			return runPerCommit(ctx, opts, gitClient, fromSHA, toSHA)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "start of the commit range (exclusive)")
+	cmd.Flags().StringVar(&to, "to", "HEAD", "end of the commit range (inclusive)")
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "only report component paths matching these globs")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "skip component paths matching these globs")
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "write the timeline to this file instead of stdout")
+	cmd.Flags().DurationVar(&buildTimeout, "build-timeout", 2*time.Minute, "per-component build timeout (0 to disable)")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newImpactCmd builds the "impact" subcommand: list which component paths
+// a set of changed files affects using the detector alone — no kustomize
+// builds — so it is fast enough for pre-commit hooks and quick triage.
+// Changed files can be passed as arguments; without arguments they come
+// from git against the base ref, including uncommitted edits.
+func newImpactCmd() *cobra.Command {
+	var (
+		baseRef      string
+		overlaysDirs []string
+	)
+	cmd := &cobra.Command{
+		Use:   "impact [file...]",
+		Short: "List affected components without building anything",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			root, err := gitClient.TopLevel(ctx)
+			if err != nil {
+				return err
+			}
+			changed := args
+			if len(changed) == 0 {
+				if baseRef == "" {
+					baseRef = git.DefaultBaseRef(ctx, gitClient)
+				}
+				headSHA, err := gitClient.ResolveRef(ctx, "HEAD")
+				if err != nil {
+					return err
+				}
+				baseSHA, err := git.MergeBaseWithFetch(ctx, gitClient, baseRef, headSHA)
+				if err != nil {
+					return err
+				}
+				// An empty head side compares against the working tree,
+				// so staged and unstaged edits count too.
+				changed, err = gitClient.ChangedFiles(ctx, baseSHA, "")
+				if err != nil {
+					return err
+				}
+			}
+			paths, err := discoverAll(root, overlaysDirs)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.Load(root)
+			if err != nil {
+				return err
+			}
+			detector := detect.New(paths)
+			if err := detector.ResolveSymlinks(root); err != nil {
+				return err
+			}
+			if err := detector.ScanKustomizeRefs(root); err != nil {
+				return err
+			}
+			detector.AddMappings(cfg.Mappings)
+			affected := detector.Affected(changed)
+			out := cmd.OutOrStdout()
+			for _, p := range affected {
+				env := p.Environment
+				if env == "" {
+					env = "-"
+				}
+				clusterDir := p.ClusterDir
+				if clusterDir == "" {
+					clusterDir = "-"
+				}
+				fmt.Fprintf(out, "%s\t%s\t%s\n", env, clusterDir, p.Path)
+			}
+			fmt.Fprintf(out, "%d changed file(s) affect %d component path(s)\n", len(changed), len(affected))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&baseRef, "base-ref", "", "base ref to diff against when no files are given (default: detected)")
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	return cmd
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+const workflowScaffold = `name: render-diff
+on:
+  pull_request:
+    branches: [main]
+jobs:
+  render-diff:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+      - name: Render diff
+        working-directory: tools/render-diff
+        env:
+          GITHUB_TOKEN: ${{ secrets.GITHUB_TOKEN }}
+        run: |
+          go run . --github-comment \
+            --github-repo "${{ github.repository }}" \
+            --pr-number "${{ github.event.pull_request.number }}"
+`
+
+// newInitCmd builds the "init" subcommand: a first-run bootstrap that
+// inspects the repository, proposes a .render-diff.yaml and can scaffold the
+// GitHub workflow, lowering the barrier for other repos adopting the tool.
+func newInitCmd() *cobra.Command {
+	var (
+		write    bool
+		workflow bool
+	)
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Inspect the repo and propose a render-diff configuration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoRoot, err := git.New(".").TopLevel(cmd.Context())
+			if err != nil {
+				return err
+			}
+			roots := findOverlayRoots(repoRoot)
+			if len(roots) == 0 {
+				return fmt.Errorf("no ApplicationSet definitions found under %s", repoRoot)
+			}
+			paths, err := discoverAll(repoRoot, roots)
+			if err != nil {
+				return err
+			}
+			proposal := proposeConfig(roots, paths)
+			fmt.Printf("Detected overlays roots: %s\n", strings.Join(roots, ", "))
+			fmt.Printf("Detected %d component paths across environments: %s\n\n", len(paths), strings.Join(environmentsOf(paths), ", "))
+			fmt.Println("Proposed " + config.FileName + ":")
+			fmt.Println(proposal)
+			if write {
+				target := filepath.Join(repoRoot, config.FileName)
+				if _, err := os.Stat(target); err == nil {
+					return fmt.Errorf("%s already exists, refusing to overwrite", config.FileName)
+				}
+				if err := os.WriteFile(target, []byte(proposal), 0o644); err != nil {
+					return err
+				}
+				fmt.Println("Wrote " + config.FileName)
+			}
+			if workflow {
+				dir := filepath.Join(repoRoot, ".github", "workflows")
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					return err
+				}
+				target := filepath.Join(dir, "render-diff.yaml")
+				if _, err := os.Stat(target); err == nil {
+					return fmt.Errorf(".github/workflows/render-diff.yaml already exists, refusing to overwrite")
+				}
+				if err := os.WriteFile(target, []byte(workflowScaffold), 0o644); err != nil {
+					return err
+				}
+				fmt.Println("Wrote .github/workflows/render-diff.yaml")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&write, "write", false, "write the proposed configuration to the repo root")
+	cmd.Flags().BoolVar(&workflow, "workflow", false, "scaffold the GitHub Actions workflow")
+	return cmd
+}
+
+// findOverlayRoots returns top-level directories containing ApplicationSet
+// definitions.
+func findOverlayRoots(repoRoot string) []string {
+	entries, err := os.ReadDir(repoRoot)
+	if err != nil {
+		return nil
+	}
+	var roots []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		paths, err := appset.Discover(repoRoot, entry.Name())
+		if err == nil && len(paths) > 0 {
+			roots = append(roots, entry.Name())
+		}
+	}
+	return roots
+}
+
+func environmentsOf(paths []appset.ComponentPath) []string {
+	seen := map[string]bool{}
+	var envs []string
+	for _, p := range paths {
+		if p.Environment != "" && !seen[p.Environment] {
+			seen[p.Environment] = true
+			envs = append(envs, p.Environment)
+		}
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+func proposeConfig(roots []string, paths []appset.ComponentPath) string {
+	var b strings.Builder
+	b.WriteString("# render-diff configuration, generated by `render-diff init`.\n")
+	b.WriteString("# Flag defaults applied to every run (CLI flags and RENDER_DIFF_* env win):\n")
+	b.WriteString("defaults:\n")
+	fmt.Fprintf(&b, "  overlays-dir: %s\n", strings.Join(roots, ","))
+	b.WriteString("# Extra detection mappings for inputs outside component directories:\n")
+	b.WriteString("# mappings:\n")
+	b.WriteString("#   - paths: [\"hack/templates/**\"]\n")
+	b.WriteString("#     components: [\"components/example\"]\n")
+	b.WriteString("# Ignore rules for components known to be noisy\n")
+	b.WriteString("# (see `render-diff report` for data-driven suggestions):\n")
+	b.WriteString("# ignore:\n")
+	b.WriteString("#   - component: components/example\n")
+	return b.String()
+}
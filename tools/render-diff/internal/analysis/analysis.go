@@ -0,0 +1,570 @@
+// Package analysis inspects rendered diffs for risk patterns — RBAC
+// escalations and similar security-relevant changes — that deserve more
+// reviewer attention than a row in the change table.
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// SecurityCategory marks findings the report pulls into its dedicated
+// "Security-relevant changes" section.
+const SecurityCategory = "security"
+
+// wildcardEntry matches an added list entry or inline list granting "*",
+// the shape RBAC wildcard verbs and resources take in rendered YAML.
+var wildcardEntry = regexp.MustCompile(`^\+\s*(-\s*['"]?\*['"]?\s*$|\w+:\s*\[\s*['"]?\*['"]?\s*\])`)
+
+// RBACFindings flags new or broadened cluster-scoped RBAC in the diffs:
+// added ClusterRoles and ClusterRoleBindings, and wildcard verbs or
+// resources appearing on added lines.
+func RBACFindings(result *engine.DiffResult) []engine.Finding {
+	var findings []engine.Finding
+	for _, c := range result.Components {
+		if c.Diff == "" {
+			continue
+		}
+		var kinds []string
+		wildcard := false
+		inRBAC := false
+		for _, line := range strings.Split(c.Diff, "\n") {
+			if kind, found := strings.CutPrefix(line, "+kind: "); found {
+				if kind == "ClusterRole" || kind == "ClusterRoleBinding" {
+					kinds = append(kinds, kind)
+				}
+			}
+			// Track whether the surrounding document is RBAC so a
+			// wildcard in, say, a ConfigMap does not fire.
+			if kind, found := strings.CutPrefix(strings.TrimPrefix(line, "+"), "kind: "); found {
+				inRBAC = strings.Contains(kind, "Role")
+			}
+			if inRBAC && wildcardEntry.MatchString(line) {
+				wildcard = true
+			}
+		}
+		for _, kind := range kinds {
+			findings = append(findings, engine.Finding{
+				Severity:  "warning",
+				Category:  SecurityCategory,
+				Component: c.Path.Path,
+				Message:   fmt.Sprintf("adds a %s", kind),
+			})
+		}
+		if wildcard {
+			findings = append(findings, engine.Finding{
+				Severity:  "critical",
+				Category:  SecurityCategory,
+				Component: c.Path.Path,
+				Message:   "grants wildcard verbs or resources in RBAC rules",
+			})
+		}
+	}
+	return findings
+}
+
+// NamespaceRemovals flags rendered Namespace resources that disappear
+// between base and head. Argo CD prunes removed resources, and deleting a
+// Namespace cascade-deletes everything inside it, so these are always
+// critical.
+func NamespaceRemovals(result *engine.DiffResult) []engine.Finding {
+	var findings []engine.Finding
+	for _, c := range result.Components {
+		for _, name := range removedKindNames(c.Diff, "Namespace") {
+			findings = append(findings, engine.Finding{
+				Severity:  "critical",
+				Category:  SecurityCategory,
+				Component: c.Path.Path,
+				Message:   fmt.Sprintf("deletes Namespace %s and, by cascade, everything in it", name),
+			})
+		}
+	}
+	return findings
+}
+
+// CRDRemovals flags CustomResourceDefinitions that disappear between base
+// and head. Removing a CRD deletes every custom resource of that type, so
+// these are always critical.
+func CRDRemovals(result *engine.DiffResult) []engine.Finding {
+	var findings []engine.Finding
+	for _, c := range result.Components {
+		for _, name := range removedKindNames(c.Diff, "CustomResourceDefinition") {
+			findings = append(findings, engine.Finding{
+				Severity:  "critical",
+				Category:  SecurityCategory,
+				Component: c.Path.Path,
+				Message:   fmt.Sprintf("removes CustomResourceDefinition %s; all custom resources of that type will be deleted", name),
+			})
+		}
+	}
+	return findings
+}
+
+// CapacityReductions warns when a production diff lowers replica counts or
+// tightens a PodDisruptionBudget — reduced maxUnavailable or raised
+// minAvailable — since both shrink serving capacity or the room operators
+// have to drain nodes.
+func CapacityReductions(result *engine.DiffResult) []engine.Finding {
+	var findings []engine.Finding
+	warn := func(c engine.ComponentDiff, format string, args ...any) {
+		findings = append(findings, engine.Finding{
+			Severity:  "warning",
+			Category:  "capacity",
+			Component: c.Path.Path,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+	for _, c := range result.Components {
+		if c.Path.Environment != "production" || c.Diff == "" {
+			continue
+		}
+		for _, ch := range numericChanges(c.Diff, "replicas") {
+			if ch.to < ch.from {
+				warn(c, "reduces replicas from %s to %s in production", ch.fromRaw, ch.toRaw)
+			}
+		}
+		for _, ch := range numericChanges(c.Diff, "minAvailable") {
+			if ch.to > ch.from {
+				warn(c, "tightens PodDisruptionBudget minAvailable from %s to %s in production", ch.fromRaw, ch.toRaw)
+			}
+		}
+		for _, ch := range numericChanges(c.Diff, "maxUnavailable") {
+			if ch.to < ch.from {
+				warn(c, "tightens PodDisruptionBudget maxUnavailable from %s to %s in production", ch.fromRaw, ch.toRaw)
+			}
+		}
+	}
+	return findings
+}
+
+// sensitiveFields are pod security settings whose modification warrants a
+// closer look than a routine diff line.
+var sensitiveFields = []string{
+	"privileged",
+	"allowPrivilegeEscalation",
+	"runAsUser",
+	"runAsNonRoot",
+	"capabilities",
+	"hostPath",
+	"hostNetwork",
+	"hostPID",
+	"hostIPC",
+}
+
+// SecurityContextFindings flags changes to security-sensitive pod fields —
+// privilege settings, user IDs, capabilities, host namespace and hostPath
+// usage — so they stand apart from routine changes in the summary.
+func SecurityContextFindings(result *engine.DiffResult) []engine.Finding {
+	var findings []engine.Finding
+	for _, c := range result.Components {
+		if c.Diff == "" {
+			continue
+		}
+		touched := map[string]bool{}
+		escalates := false
+		for _, line := range strings.Split(c.Diff, "\n") {
+			if len(line) == 0 || (line[0] != '-' && line[0] != '+') {
+				continue
+			}
+			body := strings.TrimSpace(line[1:])
+			for _, field := range sensitiveFields {
+				if !strings.HasPrefix(body, field+":") {
+					continue
+				}
+				touched[field] = true
+				if line[0] != '+' {
+					continue
+				}
+				if body == "privileged: true" || field == "hostPath" || body == "hostNetwork: true" || body == "hostPID: true" || body == "hostIPC: true" {
+					escalates = true
+				}
+			}
+		}
+		if len(touched) == 0 {
+			continue
+		}
+		var fields []string
+		for field := range touched {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		severity := "warning"
+		if escalates {
+			severity = "critical"
+		}
+		findings = append(findings, engine.Finding{
+			Severity:  severity,
+			Category:  SecurityCategory,
+			Component: c.Path.Path,
+			Message:   fmt.Sprintf("changes security-sensitive fields: %s", strings.Join(fields, ", ")),
+		})
+	}
+	return findings
+}
+
+// ImageFindings flags container image references introduced in head that
+// point outside the configured registry allowlist or use the mutable
+// :latest tag. Severity follows the policy's per-environment settings,
+// defaulting to warning.
+func ImageFindings(result *engine.DiffResult, policy config.ImagePolicy) []engine.Finding {
+	if len(policy.AllowedRegistries) == 0 {
+		return nil
+	}
+	allowed := map[string]bool{}
+	for _, r := range policy.AllowedRegistries {
+		allowed[r] = true
+	}
+	var findings []engine.Finding
+	for _, c := range result.Components {
+		if c.Diff == "" {
+			continue
+		}
+		severity := policy.Severity[c.Path.Environment]
+		if severity == "" {
+			severity = "warning"
+		}
+		for _, image := range introducedImages(c.Diff) {
+			if registry := registryOf(image); !allowed[registry] {
+				findings = append(findings, engine.Finding{
+					Severity:  severity,
+					Category:  "images",
+					Component: c.Path.Path,
+					Message:   fmt.Sprintf("introduces image %s from registry %s, which is not in the allowlist", image, registry),
+				})
+			}
+			if strings.HasSuffix(image, ":latest") {
+				findings = append(findings, engine.Finding{
+					Severity:  severity,
+					Category:  "images",
+					Component: c.Path.Path,
+					Message:   fmt.Sprintf("introduces image %s with the mutable :latest tag", image),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// introducedImages returns image references that appear on added diff
+// lines but not on removed ones, i.e. images this change brings in.
+func introducedImages(diff string) []string {
+	refs := func(sign byte) map[string]bool {
+		out := map[string]bool{}
+		for _, line := range strings.Split(diff, "\n") {
+			if len(line) == 0 || line[0] != sign {
+				continue
+			}
+			body := strings.TrimPrefix(strings.TrimSpace(line[1:]), "- ")
+			if ref, found := strings.CutPrefix(body, "image: "); found {
+				out[strings.Trim(ref, `'"`)] = true
+			}
+		}
+		return out
+	}
+	removed := refs('-')
+	var introduced []string
+	for ref := range refs('+') {
+		if !removed[ref] {
+			introduced = append(introduced, ref)
+		}
+	}
+	sort.Strings(introduced)
+	return introduced
+}
+
+// registryOf extracts the registry host of an image reference. A first
+// path segment without a dot, colon or "localhost" is a Docker Hub
+// namespace, not a registry.
+func registryOf(image string) string {
+	host, _, found := strings.Cut(image, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return "docker.io"
+	}
+	return host
+}
+
+// AppProjectFindings reports permission changes on Argo CD AppProject
+// resources — permitted source repos, destinations and cluster-resource
+// access — since a tightened project can break syncs for every Application
+// it governs.
+func AppProjectFindings(result *engine.DiffResult) []engine.Finding {
+	var findings []engine.Finding
+	note := func(c engine.ComponentDiff, format string, args ...any) {
+		findings = append(findings, engine.Finding{
+			Severity:  "warning",
+			Category:  SecurityCategory,
+			Component: c.Path.Path,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+	for _, c := range result.Components {
+		if c.Diff == "" || !strings.Contains(c.Diff, "kind: AppProject") {
+			continue
+		}
+		for _, repo := range listEntryChanges(c.Diff, "sourceRepos", '+') {
+			note(c, "AppProject grants source repo %s", repo)
+		}
+		for _, repo := range listEntryChanges(c.Diff, "sourceRepos", '-') {
+			note(c, "AppProject revokes source repo %s", repo)
+		}
+		for _, list := range []struct{ key, label string }{
+			{"destinations", "permitted destinations"},
+			{"clusterResourceWhitelist", "cluster-resource access"},
+		} {
+			added := len(listEntryChanges(c.Diff, list.key, '+'))
+			removed := len(listEntryChanges(c.Diff, list.key, '-'))
+			if added > 0 || removed > 0 {
+				note(c, "AppProject changes %s (+%d/-%d entries)", list.label, added, removed)
+			}
+		}
+	}
+	return findings
+}
+
+// listEntryChanges returns the first line of every list entry under the
+// named key that was added or removed (per sign). The key's indentation
+// delimits the list: any following line at the same or lower indent ends
+// it.
+func listEntryChanges(diff, key string, sign byte) []string {
+	var entries []string
+	inList := false
+	keyIndent := 0
+	for _, line := range strings.Split(diff, "\n") {
+		if len(line) == 0 || strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "@@") {
+			continue
+		}
+		body := line
+		if body[0] == '+' || body[0] == '-' || body[0] == ' ' {
+			body = body[1:]
+		}
+		trimmed := strings.TrimSpace(body)
+		indent := len(body) - len(strings.TrimLeft(body, " "))
+		switch {
+		case trimmed == key+":":
+			inList = true
+			keyIndent = indent
+			continue
+		case inList && trimmed != "" && indent <= keyIndent:
+			inList = false
+		}
+		if !inList || line[0] != sign {
+			continue
+		}
+		if entry, found := strings.CutPrefix(trimmed, "- "); found {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// PruneImpact explains the deletion semantics of resources that disappear
+// from head renders: whether the owning Application's sync policy would
+// prune them from the cluster or leave them orphaned. The owner definition
+// is read from the head tree under repoRoot.
+func PruneImpact(repoRoot string, result *engine.DiffResult) []engine.Finding {
+	var findings []engine.Finding
+	for _, c := range result.Components {
+		removed := removedDocCount(c.Diff)
+		if removed == 0 {
+			continue
+		}
+		prune, err := appset.PruneEnabled(repoRoot, c.Path)
+		if err != nil {
+			// Rendered children and removed owners have no definition
+			// file to consult; the semantics are simply unknown.
+			continue
+		}
+		if prune {
+			findings = append(findings, engine.Finding{
+				Severity:  "warning",
+				Category:  "prune",
+				Component: c.Path.Path,
+				Message:   fmt.Sprintf("%d removed resource(s) will be pruned from the cluster (automated prune is on)", removed),
+			})
+		} else {
+			findings = append(findings, engine.Finding{
+				Severity:  "info",
+				Category:  "prune",
+				Component: c.Path.Path,
+				Message:   fmt.Sprintf("%d removed resource(s) will be left orphaned in the cluster (automated prune is off)", removed),
+			})
+		}
+	}
+	return findings
+}
+
+// removedDocCount estimates how many documents a diff removes outright:
+// the net loss of kind lines.
+func removedDocCount(diff string) int {
+	removed, added := 0, 0
+	for _, line := range strings.Split(diff, "\n") {
+		body := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "-"), "+"))
+		if !strings.HasPrefix(body, "kind: ") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "-"):
+			removed++
+		case strings.HasPrefix(line, "+"):
+			added++
+		}
+	}
+	if removed <= added {
+		return 0
+	}
+	return removed - added
+}
+
+// SyncOrderFindings reports changes that alter the order Argo CD applies
+// resources in — sync-wave moves and hook additions or removals — since
+// apply reordering is a common cause of sync failures.
+func SyncOrderFindings(result *engine.DiffResult) []engine.Finding {
+	var findings []engine.Finding
+	note := func(c engine.ComponentDiff, format string, args ...any) {
+		findings = append(findings, engine.Finding{
+			Severity:  "warning",
+			Category:  "sync-order",
+			Component: c.Path.Path,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+	for _, c := range result.Components {
+		if c.Diff == "" {
+			continue
+		}
+		for _, ch := range numericChanges(c.Diff, "argocd.argoproj.io/sync-wave") {
+			if ch.from != ch.to {
+				note(c, "moves a resource from sync-wave %s to %s", ch.fromRaw, ch.toRaw)
+			}
+		}
+		removed, added := changedValues(c.Diff, "argocd.argoproj.io/hook")
+		for _, hook := range added {
+			note(c, "adds a %s hook", hook)
+		}
+		for _, hook := range removed {
+			note(c, "removes a %s hook", hook)
+		}
+	}
+	return findings
+}
+
+// changedValues returns the values of the named scalar field that appear
+// only on removed lines and only on added lines, respectively.
+func changedValues(diff, field string) (removed, added []string) {
+	values := func(sign byte) map[string]bool {
+		out := map[string]bool{}
+		for _, line := range strings.Split(diff, "\n") {
+			if len(line) == 0 || line[0] != sign {
+				continue
+			}
+			body := strings.TrimSpace(line[1:])
+			if v, found := strings.CutPrefix(body, field+": "); found {
+				out[strings.Trim(v, `'"`)] = true
+			}
+		}
+		return out
+	}
+	before, after := values('-'), values('+')
+	for v := range before {
+		if !after[v] {
+			removed = append(removed, v)
+		}
+	}
+	for v := range after {
+		if !before[v] {
+			added = append(added, v)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	return removed, added
+}
+
+// fieldChange is one removed/added value pair for a scalar field, with the
+// raw YAML values kept for messages (percentages survive as written).
+type fieldChange struct {
+	from, to       float64
+	fromRaw, toRaw string
+}
+
+// numericChanges pairs removed and added values of the named scalar field
+// in diff order, skipping values that do not parse as numbers.
+func numericChanges(diff, field string) []fieldChange {
+	var pending []fieldChange
+	var changes []fieldChange
+	for _, line := range strings.Split(diff, "\n") {
+		if len(line) == 0 || (line[0] != '-' && line[0] != '+') {
+			continue
+		}
+		body := strings.TrimSpace(line[1:])
+		raw, found := strings.CutPrefix(body, field+": ")
+		if !found {
+			continue
+		}
+		raw = strings.Trim(raw, `'"`)
+		value, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+		if err != nil {
+			continue
+		}
+		if line[0] == '-' {
+			pending = append(pending, fieldChange{from: value, fromRaw: raw})
+			continue
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		ch := pending[0]
+		pending = pending[1:]
+		ch.to, ch.toRaw = value, raw
+		changes = append(changes, ch)
+	}
+	return changes
+}
+
+// removedKindNames extracts the metadata names of documents of the given
+// kind that appear only on removed diff lines. Normalized renders order
+// kind before metadata, so the name is the first name field after the kind
+// line within a document.
+func removedKindNames(diff, kind string) []string {
+	if diff == "" {
+		return nil
+	}
+	names := func(sign byte) map[string]bool {
+		out := map[string]bool{}
+		pending := false
+		for _, line := range strings.Split(diff, "\n") {
+			if len(line) == 0 || line[0] != sign {
+				continue
+			}
+			body := strings.TrimSpace(line[1:])
+			if k, found := strings.CutPrefix(body, "kind: "); found {
+				pending = k == kind
+				continue
+			}
+			if pending {
+				if name, found := strings.CutPrefix(body, "name: "); found {
+					out[name] = true
+					pending = false
+				}
+			}
+		}
+		return out
+	}
+	added := names('+')
+	var removed []string
+	for name := range names('-') {
+		if !added[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
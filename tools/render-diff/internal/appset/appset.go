@@ -0,0 +1,524 @@
+// Package appset discovers the component paths managed by the Argo CD
+// ApplicationSets in this repository. It expands generator parameters into
+// the concrete spec.source.path values Argo CD would render, so the rest of
+// the tool can reason about "component X, environment Y, cluster Z".
+package appset
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComponentPath is one concrete source path an ApplicationSet renders.
+type ComponentPath struct {
+	// AppSet is the name of the ApplicationSet that owns the path.
+	AppSet string
+	// Component is the top-level component directory, e.g. "components/spi".
+	Component string
+	// Environment is the overlay environment (staging, production, ...).
+	Environment string
+	// ClusterDir is the per-cluster overlay directory ("base" for the
+	// environment-wide overlay, otherwise a cluster name).
+	ClusterDir string
+	// Path is the repo-relative directory Argo CD builds for this entry.
+	Path string
+	// File is the repo-relative path of the ApplicationSet definition.
+	File string
+}
+
+var placeholder = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// Discover walks overlaysDir (repo-relative) under repoRoot and returns the
+// component paths of every ApplicationSet found there.
+func Discover(repoRoot, overlaysDir string) ([]ComponentPath, error) {
+	var paths []ComponentPath
+	root := filepath.Join(repoRoot, overlaysDir)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (!strings.HasSuffix(p, ".yaml") && !strings.HasSuffix(p, ".yml")) {
+			return nil
+		}
+		rel, err := filepath.Rel(repoRoot, p)
+		if err != nil {
+			return err
+		}
+		found, err := parseFile(repoRoot, p, rel)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		paths = append(paths, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Path < paths[j].Path })
+	return paths, nil
+}
+
+func parseFile(repoRoot, path, rel string) ([]ComponentPath, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var paths []ComponentPath
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		var doc map[string]any
+		if err := dec.Decode(&doc); err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			// Not every yaml file in the overlays tree is a k8s
+			// resource (templates, fragments); skip what we cannot
+			// parse rather than failing discovery.
+			break
+		}
+		switch str(doc, "kind") {
+		case "ApplicationSet":
+		case "Application":
+			if p := parseApplication(doc, rel); p != nil {
+				paths = append(paths, *p)
+			}
+			continue
+		default:
+			continue
+		}
+		name := str(dig(doc, "metadata"), "name")
+		template := str(dig(doc, "spec", "template", "spec", "source"), "path")
+		if template == "" {
+			continue
+		}
+		exp := expander{repoRoot: repoRoot}
+		for _, params := range exp.expand(slice(dig(doc, "spec"), "generators")) {
+			p, ok := substitute(template, params)
+			if !ok {
+				continue
+			}
+			paths = append(paths, ComponentPath{
+				AppSet:      name,
+				Component:   componentOf(p),
+				Environment: firstOf(params, "values.environment", "environment"),
+				ClusterDir:  firstOf(params, "values.clusterDir", "clusterDir"),
+				Path:        p,
+				File:        rel,
+			})
+		}
+	}
+	return paths, nil
+}
+
+// ChildPaths extracts the component paths of Argo CD Application resources
+// inside a rendered manifest stream, so app-of-apps layers can be rendered
+// recursively. Applications whose source is not a repo-relative path are
+// skipped.
+func ChildPaths(render string) []ComponentPath {
+	var paths []ComponentPath
+	dec := yaml.NewDecoder(strings.NewReader(render))
+	for {
+		var doc map[string]any
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		if str(doc, "kind") != "Application" {
+			continue
+		}
+		if p := parseApplication(doc, "(rendered)"); p != nil {
+			paths = append(paths, *p)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Path < paths[j].Path })
+	return paths
+}
+
+// PruneEnabled reports whether the Application or ApplicationSet that owns
+// the component path syncs with automated pruning, judged from its
+// definition file under repoRoot. Unknown owners count as not pruning.
+func PruneEnabled(repoRoot string, cp ComponentPath) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, cp.File))
+	if err != nil {
+		return false, err
+	}
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		var doc map[string]any
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		if str(dig(doc, "metadata"), "name") != cp.AppSet {
+			continue
+		}
+		var automated map[string]any
+		switch str(doc, "kind") {
+		case "ApplicationSet":
+			automated = dig(doc, "spec", "template", "spec", "syncPolicy", "automated")
+		case "Application":
+			automated = dig(doc, "spec", "syncPolicy", "automated")
+		default:
+			continue
+		}
+		prune, _ := automated["prune"].(bool)
+		return prune, nil
+	}
+	return false, nil
+}
+
+// IgnoreRule is one Argo CD ignoreDifferences entry, reduced to the parts
+// the differ can honor. jqPathExpressions are not supported and dropped.
+type IgnoreRule struct {
+	Group        string
+	Kind         string
+	Name         string
+	Namespace    string
+	JSONPointers []string
+}
+
+// IgnoreDifferences returns the ignoreDifferences rules of the Application
+// or ApplicationSet that owns the component path, so the differ can strip
+// fields Argo CD itself would not act on.
+func IgnoreDifferences(repoRoot string, cp ComponentPath) ([]IgnoreRule, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, cp.File))
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		var doc map[string]any
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		if str(dig(doc, "metadata"), "name") != cp.AppSet {
+			continue
+		}
+		var entries []any
+		switch str(doc, "kind") {
+		case "ApplicationSet":
+			entries = slice(dig(doc, "spec", "template", "spec"), "ignoreDifferences")
+		case "Application":
+			entries = slice(dig(doc, "spec"), "ignoreDifferences")
+		default:
+			continue
+		}
+		var rules []IgnoreRule
+		for _, entry := range entries {
+			m, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+			rule := IgnoreRule{
+				Group:     str(m, "group"),
+				Kind:      str(m, "kind"),
+				Name:      str(m, "name"),
+				Namespace: str(m, "namespace"),
+			}
+			for _, p := range slice(m, "jsonPointers") {
+				if s, ok := p.(string); ok {
+					rule.JSONPointers = append(rule.JSONPointers, s)
+				}
+			}
+			if len(rule.JSONPointers) > 0 {
+				rules = append(rules, rule)
+			}
+		}
+		return rules, nil
+	}
+	return nil, nil
+}
+
+// parseApplication maps a standalone Application resource to a component
+// path. Environment and cluster are inferred from the conventional
+// .../overlays/<environment>/<clusterDir> layout when present.
+func parseApplication(doc map[string]any, rel string) *ComponentPath {
+	source := dig(doc, "spec", "source")
+	path := str(source, "path")
+	if path == "" {
+		return nil
+	}
+	environment, clusterDir := overlayOf(path)
+	return &ComponentPath{
+		AppSet:      str(dig(doc, "metadata"), "name"),
+		Component:   componentOf(path),
+		Environment: environment,
+		ClusterDir:  clusterDir,
+		Path:        path,
+		File:        rel,
+	}
+}
+
+// overlayOf extracts the environment and cluster directory from a source
+// path following the components/<name>/overlays/<env>[/<cluster>] layout.
+func overlayOf(path string) (environment, clusterDir string) {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if p != "overlays" {
+			continue
+		}
+		if i+1 < len(parts) {
+			environment = parts[i+1]
+		}
+		if i+2 < len(parts) {
+			clusterDir = parts[i+2]
+		}
+		break
+	}
+	return environment, clusterDir
+}
+
+// expander turns generator definitions into flat parameter maps, one per
+// generated Application. List, clusters-with-values, merge, matrix and
+// git-directory generators are supported; git-directory candidates are
+// enumerated against the local checkout under repoRoot.
+type expander struct {
+	repoRoot string
+}
+
+func (x expander) expand(generators []any) []map[string]string {
+	var out []map[string]string
+	for _, g := range generators {
+		gen, ok := g.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch {
+		case gen["list"] != nil:
+			for _, e := range slice(dig(gen, "list"), "elements") {
+				if m, ok := e.(map[string]any); ok {
+					out = append(out, flatten(m, ""))
+				}
+			}
+		case gen["clusters"] != nil:
+			params := flatten(dig(gen, "clusters", "values"), "values.")
+			out = append(out, params)
+		case gen["git"] != nil:
+			out = append(out, x.expandGit(dig(gen, "git"))...)
+		case gen["merge"] != nil:
+			out = append(out, x.expandMerge(dig(gen, "merge"))...)
+		case gen["matrix"] != nil:
+			out = append(out, x.expandMatrix(dig(gen, "matrix"))...)
+		}
+	}
+	return out
+}
+
+// expandGit enumerates a git-directory generator's candidate paths against
+// the local checkout, assuming the generator points back at this repository
+// (the only pattern used here). Each match yields the parameters Argo CD
+// exposes: path, path.basename, path.basenameNormalized and path[n].
+func (x expander) expandGit(gen map[string]any) []map[string]string {
+	var include, exclude []string
+	for _, d := range slice(gen, "directories") {
+		dir, ok := d.(map[string]any)
+		if !ok {
+			continue
+		}
+		pattern := str(dir, "path")
+		if pattern == "" {
+			continue
+		}
+		if b, _ := dir["exclude"].(bool); b {
+			exclude = append(exclude, pattern)
+		} else {
+			include = append(include, pattern)
+		}
+	}
+	var out []map[string]string
+	for _, pattern := range include {
+		matches, err := filepath.Glob(filepath.Join(x.repoRoot, filepath.FromSlash(pattern)))
+		if err != nil {
+			continue
+		}
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(x.repoRoot, m)
+			if err != nil {
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+			if matchesAny(exclude, rel) {
+				continue
+			}
+			params := map[string]string{
+				"path":                    rel,
+				"path.basename":           filepath.Base(rel),
+				"path.basenameNormalized": normalize(filepath.Base(rel)),
+			}
+			for i, seg := range strings.Split(rel, "/") {
+				params[fmt.Sprintf("path[%d]", i)] = seg
+			}
+			out = append(out, params)
+		}
+	}
+	return out
+}
+
+func matchesAny(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize mirrors Argo CD's basenameNormalized: lower-cased with
+// non-alphanumeric runs collapsed to "-".
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, s)
+}
+
+// expandMerge implements the subset of the merge generator this repo uses:
+// the first child generator supplies defaults and each element of the
+// remaining children overrides them, matched by mergeKeys.
+func (x expander) expandMerge(merge map[string]any) []map[string]string {
+	children := slice(merge, "generators")
+	if len(children) == 0 {
+		return nil
+	}
+	base := x.expand(children[:1])
+	rest := x.expand(children[1:])
+	if len(rest) == 0 {
+		return base
+	}
+	var defaults map[string]string
+	if len(base) > 0 {
+		defaults = base[0]
+	}
+	var out []map[string]string
+	for _, overlay := range rest {
+		merged := map[string]string{}
+		for k, v := range defaults {
+			merged[k] = v
+		}
+		for k, v := range overlay {
+			merged[k] = v
+		}
+		out = append(out, merged)
+	}
+	return out
+}
+
+// expandMatrix produces the cross product of its child generators'
+// parameters, with later children overriding keys of earlier ones.
+func (x expander) expandMatrix(matrix map[string]any) []map[string]string {
+	children := slice(matrix, "generators")
+	if len(children) == 0 {
+		return nil
+	}
+	out := x.expand(children[:1])
+	for _, child := range children[1:] {
+		next := x.expand([]any{child})
+		if len(next) == 0 {
+			continue
+		}
+		var crossed []map[string]string
+		for _, a := range out {
+			for _, b := range next {
+				merged := map[string]string{}
+				for k, v := range a {
+					merged[k] = v
+				}
+				for k, v := range b {
+					merged[k] = v
+				}
+				crossed = append(crossed, merged)
+			}
+		}
+		out = crossed
+	}
+	return out
+}
+
+// substitute resolves {{param}} placeholders in template. It reports false
+// when a placeholder has no value, meaning the path cannot be enumerated
+// statically.
+func substitute(template string, params map[string]string) (string, bool) {
+	ok := true
+	result := placeholder.ReplaceAllStringFunc(template, func(m string) string {
+		key := strings.TrimSpace(m[2 : len(m)-2])
+		if v, found := params[key]; found {
+			return v
+		}
+		ok = false
+		return m
+	})
+	return result, ok
+}
+
+// componentOf maps a source path to its top-level component directory.
+func componentOf(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) >= 2 && parts[0] == "components" {
+		return parts[0] + "/" + parts[1]
+	}
+	if len(parts) > 0 {
+		return parts[0]
+	}
+	return path
+}
+
+func firstOf(params map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := params[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// flatten converts nested maps into dotted keys ("values.clusterDir"),
+// matching how Argo CD exposes generator parameters to templates.
+func flatten(m map[string]any, prefix string) map[string]string {
+	out := map[string]string{}
+	for k, v := range m {
+		switch val := v.(type) {
+		case map[string]any:
+			for fk, fv := range flatten(val, prefix+k+".") {
+				out[fk] = fv
+			}
+		case string:
+			out[prefix+k] = val
+		default:
+			out[prefix+k] = fmt.Sprintf("%v", val)
+		}
+	}
+	return out
+}
+
+func dig(m map[string]any, keys ...string) map[string]any {
+	for _, k := range keys {
+		next, ok := m[k].(map[string]any)
+		if !ok {
+			return nil
+		}
+		m = next
+	}
+	return m
+}
+
+func str(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func slice(m map[string]any, key string) []any {
+	s, _ := m[key].([]any)
+	return s
+}
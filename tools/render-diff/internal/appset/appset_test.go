@@ -0,0 +1,168 @@
+package appset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeRepo lays out a minimal repo: an overlays dir with the given
+// ApplicationSet definition and any component directories the generators
+// enumerate.
+func writeRepo(t *testing.T, appSet string, dirs ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "argo-cd-apps"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "argo-cd-apps", "all.yaml"), []byte(appSet), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestDiscoverListGenerator(t *testing.T) {
+	root := writeRepo(t, `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: all-components
+spec:
+  generators:
+    - list:
+        elements:
+          - component: spi
+            environment: staging
+          - component: monitoring
+            environment: production
+  template:
+    spec:
+      source:
+        path: components/{{component}}/overlays/{{environment}}
+`)
+	paths, err := Discover(root, "argo-cd-apps")
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("got %d paths, want 2: %+v", len(paths), paths)
+	}
+	got := paths[1]
+	if got.Path != "components/spi/overlays/staging" ||
+		got.AppSet != "all-components" ||
+		got.Component != "components/spi" ||
+		got.Environment != "staging" {
+		t.Fatalf("unexpected path: %+v", got)
+	}
+}
+
+func TestDiscoverMatrixGenerator(t *testing.T) {
+	root := writeRepo(t, `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: matrixed
+spec:
+  generators:
+    - matrix:
+        generators:
+          - list:
+              elements:
+                - component: spi
+                - component: monitoring
+          - list:
+              elements:
+                - environment: staging
+                - environment: production
+  template:
+    spec:
+      source:
+        path: components/{{component}}/overlays/{{environment}}
+`)
+	paths, err := Discover(root, "argo-cd-apps")
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(paths) != 4 {
+		t.Fatalf("matrix should cross 2x2, got %d: %+v", len(paths), paths)
+	}
+}
+
+func TestDiscoverGitGenerator(t *testing.T) {
+	root := writeRepo(t, `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: by-directory
+spec:
+  generators:
+    - git:
+        directories:
+          - path: components/*/overlays/staging
+  template:
+    spec:
+      source:
+        path: '{{path}}'
+`, "components/spi/overlays/staging", "components/monitoring/overlays/staging")
+	paths, err := Discover(root, "argo-cd-apps")
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("got %d paths, want 2: %+v", len(paths), paths)
+	}
+	if paths[1].Path != "components/spi/overlays/staging" {
+		t.Fatalf("unexpected path: %+v", paths[1])
+	}
+}
+
+func TestDiscoverSkipsUnresolvablePlaceholders(t *testing.T) {
+	root := writeRepo(t, `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: partial
+spec:
+  generators:
+    - list:
+        elements:
+          - component: spi
+  template:
+    spec:
+      source:
+        path: components/{{component}}/overlays/{{environment}}
+`)
+	paths, err := Discover(root, "argo-cd-apps")
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(paths) != 0 {
+		t.Fatalf("paths with unresolved placeholders must be skipped, got %+v", paths)
+	}
+}
+
+func TestChildPaths(t *testing.T) {
+	render := `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: child
+spec:
+  source:
+    path: components/spi/overlays/production/kflux-prd-rh02
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: not-an-app
+`
+	paths := ChildPaths(render)
+	if len(paths) != 1 {
+		t.Fatalf("got %d paths, want 1: %+v", len(paths), paths)
+	}
+	got := paths[0]
+	if got.Path != "components/spi/overlays/production/kflux-prd-rh02" ||
+		got.Environment != "production" || got.ClusterDir != "kflux-prd-rh02" {
+		t.Fatalf("unexpected child path: %+v", got)
+	}
+}
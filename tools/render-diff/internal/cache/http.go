@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// HTTPRenderCache is a shared render cache behind any blob endpoint that
+// accepts GET and PUT of opaque objects — an S3 bucket (directly or via a
+// presigning gateway), GCS's XML API, or a plain nginx with WebDAV. All CI
+// runners and developers pointing at the same endpoint share each other's
+// builds. A bearer token is read from RENDER_DIFF_CACHE_TOKEN when set.
+type HTTPRenderCache struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPRenderCache returns a cache rooted at baseURL; namespace separates
+// builder configurations, mirroring the on-disk store's layout.
+func NewHTTPRenderCache(baseURL, namespace string) *HTTPRenderCache {
+	base := strings.TrimSuffix(baseURL, "/")
+	if namespace != "" {
+		base += "/" + namespace
+	}
+	return &HTTPRenderCache{
+		base:   base,
+		token:  os.Getenv("RENDER_DIFF_CACHE_TOKEN"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *HTTPRenderCache) url(key string) string {
+	return c.base + "/" + key + ".yaml"
+}
+
+// Get fetches the stored render for key; any error or non-200 is a miss.
+func (c *HTTPRenderCache) Get(key string) (string, bool) {
+	req, err := http.NewRequest(http.MethodGet, c.url(key), nil)
+	if err != nil {
+		return "", false
+	}
+	c.authorize(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Put uploads a render, best effort: an unreachable or read-only endpoint
+// only costs other runners a rebuild.
+func (c *HTTPRenderCache) Put(key, render string) {
+	req, err := http.NewRequest(http.MethodPut, c.url(key), strings.NewReader(render))
+	if err != nil {
+		return
+	}
+	c.authorize(req)
+	req.Header.Set("Content-Type", "text/yaml")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func (c *HTTPRenderCache) authorize(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MirrorRemotes maintains local mirror clones of the git repositories behind
+// remote kustomize bases and returns GIT_CONFIG_* environment entries that
+// redirect clones of those URLs to the mirrors. Each repository is fetched
+// at most once per run instead of once per build side per component.
+// Mirroring is best effort: a repository that cannot be mirrored is skipped
+// and builds fetch it directly.
+func MirrorRemotes(ctx context.Context, refs []string) ([]string, error) {
+	root, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	urls := map[string]bool{}
+	for _, ref := range refs {
+		if url := RepoURL(ref); url != "" {
+			urls[url] = true
+		}
+	}
+	sorted := make([]string, 0, len(urls))
+	for url := range urls {
+		sorted = append(sorted, url)
+	}
+	sort.Strings(sorted)
+	var env []string
+	count := 0
+	for _, url := range sorted {
+		dir := filepath.Join(root, "remotes", shortHash(url))
+		if err := mirror(ctx, url, dir); err != nil {
+			slog.Warn("mirroring remote base failed, builds will fetch it directly", "url", url, "error", err)
+			continue
+		}
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=url.%s.insteadOf", count, dir),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, url))
+		count++
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", count)), nil
+}
+
+// mirror creates or refreshes a bare mirror clone of url at dir.
+func mirror(ctx context.Context, url, dir string) error {
+	var cmd *exec.Cmd
+	if _, err := os.Stat(dir); err == nil {
+		cmd = exec.CommandContext(ctx, "git", "-C", dir, "fetch", "--quiet", "--prune", "origin")
+	} else {
+		cmd = exec.CommandContext(ctx, "git", "clone", "--quiet", "--mirror", url, dir)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// RepoURL reduces a kustomize remote base reference to the repository URL:
+// "https://github.com/org/repo//overlays/prod?ref=v1" becomes
+// "https://github.com/org/repo". Non-URL references yield "".
+func RepoURL(ref string) string {
+	scheme, rest, found := strings.Cut(ref, "://")
+	if !found {
+		return ""
+	}
+	if idx := strings.Index(rest, "//"); idx >= 0 {
+		rest = rest[:idx]
+	}
+	if idx := strings.IndexByte(rest, '?'); idx >= 0 {
+		rest = rest[:idx]
+	}
+	return scheme + "://" + rest
+}
+
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
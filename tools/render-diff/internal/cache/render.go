@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// RenderStore is a disk-backed cache of normalized renders keyed by
+// input-tree content hash, one file per render under a stable directory —
+// the layout a CI cache action can save and restore wholesale.
+type RenderStore struct {
+	dir string
+}
+
+// OpenRenderStore opens (creating if needed) a render store at dir, or
+// under the shared cache directory when dir is empty. namespace separates
+// builder configurations whose output differs for the same inputs.
+func OpenRenderStore(dir, namespace string) (*RenderStore, error) {
+	if dir == "" {
+		root, err := Dir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(root, "renders")
+	}
+	if namespace != "" {
+		dir = filepath.Join(dir, namespace)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &RenderStore{dir: dir}, nil
+}
+
+// path shards entries by the first two hash characters to keep directory
+// listings manageable on a repo with hundreds of components.
+func (s *RenderStore) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(s.dir, key+".yaml")
+	}
+	return filepath.Join(s.dir, key[:2], key+".yaml")
+}
+
+// Get returns the stored render for key, if any.
+func (s *RenderStore) Get(key string) (string, bool) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Put stores a render, best effort: a failed write only costs a rebuild on
+// the next run. The write-then-rename keeps concurrent runs from reading a
+// partial entry.
+func (s *RenderStore) Put(key, render string) {
+	dest := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".put-*")
+	if err != nil {
+		return
+	}
+	if _, err := tmp.WriteString(render); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
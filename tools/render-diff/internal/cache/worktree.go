@@ -0,0 +1,87 @@
+// Package cache manages reusable on-disk state shared across render-diff
+// invocations.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// readyMarker flags a cached worktree whose checkout completed; a directory
+// without it is a leftover from an interrupted run and gets recreated.
+const readyMarker = ".render-diff-ready"
+
+// Dir returns the render-diff cache root under the user cache directory.
+func Dir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "render-diff"), nil
+}
+
+// WorktreeFor returns a worktree directory for sha, reusing a previously
+// created checkout when available. Creation runs under a lock so concurrent
+// invocations iterating on the same PR do not race. Cached worktrees are
+// full checkouts and stay in place for the next run.
+func WorktreeFor(ctx context.Context, sha string, create func(dir string) error) (string, error) {
+	root, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, "worktrees", sha)
+	unlock, err := lock(ctx, dir+".lock")
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if _, err := os.Stat(filepath.Join(dir, readyMarker)); err == nil {
+		return dir, nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := create(dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, readyMarker), nil, 0o644); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// lock takes an exclusive advisory lock by creating path, waiting for a
+// concurrent holder to release it. Locks older than ten minutes are
+// considered stale and broken.
+func lock(ctx context.Context, path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, serr := os.Stat(path); serr == nil && time.Since(info.ModTime()) > 10*time.Minute {
+			os.Remove(path)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package capacity estimates whether the resource-request delta of a
+// rendered diff fits the available headroom of the target cluster, using
+// either a static per-cluster capacity file or a capacity HTTP endpoint.
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Headroom is the schedulable capacity still available on a cluster.
+type Headroom struct {
+	// CPU is available capacity in millicores.
+	CPU int64 `json:"cpu" yaml:"-"`
+	// Memory is available capacity in bytes.
+	Memory int64 `json:"memory" yaml:"-"`
+}
+
+// Source provides headroom per cluster.
+type Source interface {
+	Headroom(ctx context.Context, cluster string) (*Headroom, error)
+}
+
+// FileSource reads headroom from a YAML file of the form:
+//
+//	clusters:
+//	  stone-prd-m01:
+//	    cpu: 12500m
+//	    memory: 48Gi
+type FileSource struct {
+	Path string
+}
+
+func (s *FileSource) Headroom(ctx context.Context, cluster string) (*Headroom, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Clusters map[string]struct {
+			CPU    string `yaml:"cpu"`
+			Memory string `yaml:"memory"`
+		} `yaml:"clusters"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", s.Path, err)
+	}
+	entry, ok := doc.Clusters[cluster]
+	if !ok {
+		return nil, nil // unknown cluster: no feasibility statement
+	}
+	cpu, err := ParseCPU(entry.CPU)
+	if err != nil {
+		return nil, err
+	}
+	mem, err := ParseMemory(entry.Memory)
+	if err != nil {
+		return nil, err
+	}
+	return &Headroom{CPU: cpu, Memory: mem}, nil
+}
+
+// HTTPSource queries GET <endpoint>/clusters/<name>, expecting a JSON
+// Headroom body with cpu in millicores and memory in bytes.
+type HTTPSource struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+func (s *HTTPSource) Headroom(ctx context.Context, cluster string) (*Headroom, error) {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	url := strings.TrimSuffix(s.Endpoint, "/") + "/clusters/" + cluster
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("capacity endpoint returned %s for %s", resp.Status, cluster)
+	}
+	var h Headroom
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// ParseCPU parses a Kubernetes CPU quantity into millicores.
+func ParseCPU(q string) (int64, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(q, "m") {
+		return strconv.ParseInt(strings.TrimSuffix(q, "m"), 10, 64)
+	}
+	cores, err := strconv.ParseFloat(q, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu quantity %q", q)
+	}
+	return int64(cores * 1000), nil
+}
+
+// ParseMemory parses a Kubernetes memory quantity into bytes.
+func ParseMemory(q string) (int64, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return 0, nil
+	}
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+		{"k", 1000}, {"M", 1000 * 1000}, {"G", 1000 * 1000 * 1000},
+	}
+	for _, u := range units {
+		if strings.HasSuffix(q, u.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(q, u.suffix), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid memory quantity %q", q)
+			}
+			return int64(n * float64(u.factor)), nil
+		}
+	}
+	n, err := strconv.ParseInt(q, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory quantity %q", q)
+	}
+	return n, nil
+}
+
+// Delta is the aggregate request change a diff introduces.
+type Delta struct {
+	CPU    int64
+	Memory int64
+}
+
+// DeltaFromDiff approximates the request delta of a unified diff by summing
+// cpu/memory quantities on added lines and subtracting those on removed
+// lines. It is a heuristic: it reads any "cpu:"/"memory:" value, so limits
+// count as well, erring on the conservative side.
+func DeltaFromDiff(diff string) Delta {
+	var d Delta
+	for _, line := range strings.Split(diff, "\n") {
+		sign := int64(0)
+		switch {
+		case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+			sign = 1
+		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
+			sign = -1
+		default:
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimSpace(line[1:]), ":")
+		if !found {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch key {
+		case "cpu":
+			if v, err := ParseCPU(value); err == nil {
+				d.CPU += sign * v
+			}
+		case "memory":
+			if v, err := ParseMemory(value); err == nil {
+				d.Memory += sign * v
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,74 @@
+package capacity
+
+import "testing"
+
+func TestParseCPU(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"250m", 250, false},
+		{"2", 2000, false},
+		{"1.5", 1500, false},
+		{" 500m ", 500, false},
+		{"lots", 0, true},
+	}
+	for _, c := range cases {
+		got, err := ParseCPU(c.in)
+		if (err != nil) != c.wantErr {
+			t.Errorf("ParseCPU(%q) err = %v, wantErr %v", c.in, err, c.wantErr)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseCPU(%q) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1024", 1024, false},
+		{"1Ki", 1 << 10, false},
+		{"2Mi", 2 << 20, false},
+		{"1.5Gi", 3 << 29, false},
+		{"1k", 1000, false},
+		{"3G", 3_000_000_000, false},
+		{"plenty", 0, true},
+	}
+	for _, c := range cases {
+		got, err := ParseMemory(c.in)
+		if (err != nil) != c.wantErr {
+			t.Errorf("ParseMemory(%q) err = %v, wantErr %v", c.in, err, c.wantErr)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseMemory(%q) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDeltaFromDiff(t *testing.T) {
+	diff := `--- base/x
++++ head/x
+@@ -1,4 +1,4 @@
+         memory: 64Mi
+-        cpu: 250m
++        cpu: 500m
++        memory: "1Gi"
+-        memory: 512Mi
+`
+	d := DeltaFromDiff(diff)
+	if d.CPU != 250 {
+		t.Errorf("CPU delta = %d, want 250", d.CPU)
+	}
+	if want := int64(1<<30 - 512<<20); d.Memory != want {
+		t.Errorf("Memory delta = %d, want %d", d.Memory, want)
+	}
+}
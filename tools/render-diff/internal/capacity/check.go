@@ -0,0 +1,50 @@
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// Check aggregates the production request delta per cluster and compares it
+// against the source's headroom, returning a finding for every cluster the
+// change would not fit.
+func Check(ctx context.Context, src Source, result *engine.DiffResult) []engine.Finding {
+	deltas := map[string]Delta{}
+	for _, c := range result.Components {
+		if c.Path.Environment != "production" || c.Diff == "" {
+			continue
+		}
+		d := DeltaFromDiff(c.Diff)
+		agg := deltas[c.Path.ClusterDir]
+		agg.CPU += d.CPU
+		agg.Memory += d.Memory
+		deltas[c.Path.ClusterDir] = agg
+	}
+	var findings []engine.Finding
+	for cluster, delta := range deltas {
+		if delta.CPU <= 0 && delta.Memory <= 0 {
+			continue
+		}
+		headroom, err := src.Headroom(ctx, cluster)
+		if err != nil {
+			slog.Warn("capacity lookup failed", "cluster", cluster, "error", err)
+			continue
+		}
+		if headroom == nil {
+			continue
+		}
+		if delta.CPU > headroom.CPU || delta.Memory > headroom.Memory {
+			findings = append(findings, engine.Finding{
+				Severity: "warning",
+				Category: "capacity",
+				Message: fmt.Sprintf(
+					"cluster %s: request delta (+%dm cpu, +%dMi memory) exceeds available headroom (%dm cpu, %dMi memory)",
+					cluster, delta.CPU, delta.Memory/(1<<20), headroom.CPU, headroom.Memory/(1<<20)),
+			})
+		}
+	}
+	return findings
+}
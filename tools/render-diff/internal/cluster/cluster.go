@@ -0,0 +1,108 @@
+// Package cluster fetches the live state of rendered objects with kubectl,
+// so head renders can be diffed against what is actually running — the
+// moral equivalent of `argocd app diff` without an Argo CD server.
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/diffutil"
+)
+
+// serverSideFields are metadata entries the API server manages; they differ
+// on every object and would drown the diff in noise.
+var serverSideFields = []string{
+	"creationTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"uid",
+}
+
+// LiveRender fetches the current cluster state of every document in the
+// head render and returns it normalized, ready to diff against the render.
+// Objects the cluster does not have render as absent. kubeconfig may be
+// empty to use the ambient configuration.
+func LiveRender(ctx context.Context, kubeconfig, headRender string) (string, error) {
+	var live strings.Builder
+	dec := yaml.NewDecoder(strings.NewReader(headRender))
+	for {
+		var doc map[string]any
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		obj, err := fetch(ctx, kubeconfig, doc)
+		if err != nil {
+			return "", err
+		}
+		if obj != "" {
+			live.WriteString(obj)
+			live.WriteString("\n---\n")
+		}
+	}
+	return diffutil.Normalize([]byte(live.String()))
+}
+
+// fetch retrieves one object as scrubbed YAML, or "" when it does not
+// exist in the cluster.
+func fetch(ctx context.Context, kubeconfig string, doc map[string]any) (string, error) {
+	apiVersion, _ := doc["apiVersion"].(string)
+	kind, _ := doc["kind"].(string)
+	meta, _ := doc["metadata"].(map[string]any)
+	name, _ := meta["name"].(string)
+	namespace, _ := meta["namespace"].(string)
+	if kind == "" || name == "" {
+		return "", nil
+	}
+	resource := strings.ToLower(kind)
+	if group, _, found := strings.Cut(apiVersion, "/"); found {
+		resource += "." + group
+	}
+	args := []string{"get", resource, name, "--ignore-not-found", "-o", "yaml"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	if kubeconfig != "" {
+		args = append(args, "--kubeconfig", kubeconfig)
+	}
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("kubectl get %s/%s: %v: %s", kind, name, err, strings.TrimSpace(errOut.String()))
+	}
+	if out.Len() == 0 {
+		return "", nil
+	}
+	return scrub(out.Bytes())
+}
+
+// scrub drops server-managed metadata and status so only the declared
+// state is compared.
+func scrub(raw []byte) (string, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return "", err
+	}
+	delete(doc, "status")
+	if meta, ok := doc["metadata"].(map[string]any); ok {
+		for _, field := range serverSideFields {
+			delete(meta, field)
+		}
+		if annotations, ok := meta["annotations"].(map[string]any); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			if len(annotations) == 0 {
+				delete(meta, "annotations")
+			}
+		}
+	}
+	out, err := yaml.Marshal(doc)
+	return string(out), err
+}
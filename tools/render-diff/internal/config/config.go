@@ -0,0 +1,80 @@
+// Package config loads the repo-level render-diff configuration from
+// .render-diff.yaml at the repository root.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the configuration file looked up at the repository root.
+const FileName = ".render-diff.yaml"
+
+// Mapping declares that changes under certain paths affect certain
+// components, covering inputs (templates, generators) that live outside the
+// component directories themselves.
+type Mapping struct {
+	// Paths are glob patterns of input files; "dir/**" matches the whole
+	// subtree.
+	Paths []string `yaml:"paths"`
+	// Components are the component directories whose renders depend on
+	// those inputs, e.g. "components/monitoring".
+	Components []string `yaml:"components"`
+}
+
+// PluginPolicy allowlists the KRM functions and exec plugins builds may
+// run. Plugins stay disabled entirely until --enable-plugins is passed.
+type PluginPolicy struct {
+	// Allow holds container images ("gcr.io/kpt-fn/set-labels") and exec
+	// plugin paths; glob patterns are supported. A build referencing a
+	// plugin not listed here fails instead of executing it.
+	Allow []string `yaml:"allow"`
+}
+
+// ImagePolicy restricts where container images introduced by a change may
+// come from. An empty registry list disables the check.
+type ImagePolicy struct {
+	// AllowedRegistries lists registry hosts ("quay.io",
+	// "registry.redhat.io") images may reference. Images without a registry
+	// host resolve to docker.io.
+	AllowedRegistries []string `yaml:"allowedRegistries"`
+	// Severity overrides the finding severity per environment
+	// ("production: critical"). Environments not listed default to warning.
+	Severity map[string]string `yaml:"severity"`
+}
+
+// Config is the root of .render-diff.yaml.
+type Config struct {
+	Mappings []Mapping    `yaml:"mappings"`
+	Plugins  PluginPolicy `yaml:"plugins"`
+	Images   ImagePolicy  `yaml:"images"`
+	// Substitute maps an environment to a KEY=VALUE variable file whose
+	// values replace ${VAR} placeholders in that environment's renders.
+	Substitute map[string]string `yaml:"substitute"`
+	// Defaults maps flag names to values applied when the flag is not
+	// passed on the command line ("output: markdown"), so teams keep their
+	// standing invocation in the repo instead of in every CI job. Values
+	// use the flag's CLI syntax; repeatable flags take comma-separated
+	// lists.
+	Defaults map[string]string `yaml:"defaults"`
+}
+
+// Load reads the configuration from repoRoot. A missing file yields an
+// empty configuration.
+func Load(repoRoot string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, FileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", FileName, err)
+	}
+	return &cfg, nil
+}
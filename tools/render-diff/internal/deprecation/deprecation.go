@@ -0,0 +1,127 @@
+// Package deprecation reads per-component deprecation markers, so PRs that
+// touch sunset components get a prominent warning pointing at the migration
+// target.
+package deprecation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// MarkerFile is the metadata file that marks a component as deprecated,
+// placed in the component's top-level directory.
+const MarkerFile = ".deprecated.yaml"
+
+// Notice is the content of a deprecation marker.
+type Notice struct {
+	// Reason explains why the component is deprecated.
+	Reason string `yaml:"reason"`
+	// MigrateTo names the replacement, e.g. "components/new-controller".
+	MigrateTo string `yaml:"migrateTo"`
+	// Sunset is the planned removal date (YYYY-MM-DD), informational.
+	Sunset string `yaml:"sunset"`
+}
+
+// Load reads the deprecation notice of a component directory; nil when the
+// component is not deprecated.
+func Load(repoRoot, component string) (*Notice, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, component, MarkerFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var n Notice
+	if err := yaml.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("parsing %s/%s: %w", component, MarkerFile, err)
+	}
+	return &n, nil
+}
+
+func (n *Notice) message(component string) string {
+	msg := fmt.Sprintf("component %s is deprecated", component)
+	if n.Reason != "" {
+		msg += ": " + n.Reason
+	}
+	if n.MigrateTo != "" {
+		msg += fmt.Sprintf(" (migrate to %s)", n.MigrateTo)
+	}
+	if n.Sunset != "" {
+		msg += fmt.Sprintf("; sunset %s", n.Sunset)
+	}
+	return msg
+}
+
+func (n *Notice) severity() string {
+	if n.Sunset != "" {
+		if sunset, err := time.Parse("2006-01-02", n.Sunset); err == nil && time.Now().After(sunset) {
+			return "critical"
+		}
+	}
+	return "warning"
+}
+
+// FindingsFor returns one finding per deprecated component that changed in
+// the result.
+func FindingsFor(repoRoot string, result *engine.DiffResult) ([]engine.Finding, error) {
+	var findings []engine.Finding
+	seen := map[string]bool{}
+	for _, c := range result.Components {
+		if (c.Diff == "" && c.Error == "") || seen[c.Path.Component] {
+			continue
+		}
+		seen[c.Path.Component] = true
+		notice, err := Load(repoRoot, c.Path.Component)
+		if err != nil {
+			return nil, err
+		}
+		if notice == nil {
+			continue
+		}
+		findings = append(findings, engine.Finding{
+			Severity:  notice.severity(),
+			Category:  "deprecation",
+			Component: c.Path.Component,
+			Message:   notice.message(c.Path.Component),
+		})
+	}
+	return findings, nil
+}
+
+// Active lists every deprecated component that still renders somewhere,
+// keyed by component directory.
+func Active(repoRoot string, paths []appset.ComponentPath) (map[string]Notice, error) {
+	out := map[string]Notice{}
+	for _, p := range paths {
+		if _, seen := out[p.Component]; seen {
+			continue
+		}
+		notice, err := Load(repoRoot, p.Component)
+		if err != nil {
+			return nil, err
+		}
+		if notice != nil {
+			out[p.Component] = *notice
+		}
+	}
+	return out, nil
+}
+
+// Components returns the sorted keys of a notices map.
+func Components(notices map[string]Notice) []string {
+	keys := make([]string, 0, len(notices))
+	for k := range notices {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,217 @@
+// Package detect maps the files changed in a pull request to the component
+// paths whose rendered output could be affected.
+package detect
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+)
+
+// Detector answers "which component paths does this set of changed files
+// touch?" for a discovered set of ApplicationSet paths.
+type Detector struct {
+	paths []appset.ComponentPath
+	// aliases maps symlink targets to the symlink locations pointing at
+	// them, both repo-relative; see ResolveSymlinks.
+	aliases map[string][]string
+	// mappings are extra path-to-component rules from the repo config.
+	mappings []config.Mapping
+	// deps maps a rendered path to shared directories its kustomization
+	// references; see ScanKustomizeRefs.
+	deps map[string][]string
+	// remotes collects remote base references seen while scanning.
+	remotes map[string]bool
+}
+
+// AddMappings registers configured detection mappings, so changes under
+// declared input paths mark the listed components as affected even though
+// the files live outside the component directories.
+func (d *Detector) AddMappings(mappings []config.Mapping) {
+	d.mappings = append(d.mappings, mappings...)
+}
+
+// mappedComponents returns the components pulled in by configured mappings
+// for the changed files.
+func (d *Detector) mappedComponents(changed []string) map[string]bool {
+	out := map[string]bool{}
+	for _, m := range d.mappings {
+		for _, pattern := range m.Paths {
+			for _, f := range changed {
+				if !matchPattern(pattern, f) {
+					continue
+				}
+				for _, c := range m.Components {
+					out[c] = true
+				}
+				break
+			}
+		}
+	}
+	return out
+}
+
+// matchPattern matches file against a glob pattern, treating a trailing
+// "/**" as "anything under this directory".
+func matchPattern(pattern, file string) bool {
+	if prefix, found := strings.CutSuffix(pattern, "/**"); found {
+		return under(file, prefix)
+	}
+	ok, _ := path.Match(pattern, file)
+	return ok
+}
+
+// New returns a Detector over the given component paths.
+func New(paths []appset.ComponentPath) *Detector {
+	return &Detector{paths: paths}
+}
+
+// Paths returns every known component path.
+func (d *Detector) Paths() []appset.ComponentPath {
+	return d.paths
+}
+
+// Deps returns the shared directories and files a rendered path consumes,
+// as recorded by ScanKustomizeRefs.
+func (d *Detector) Deps(path string) []string {
+	return d.deps[path]
+}
+
+// ResolveSymlinks scans repoRoot for symlinks pointing back into the
+// repository and records them as aliases, so a change to a shared directory
+// is also attributed to every component that consumes it via a symlink.
+func (d *Detector) ResolveSymlinks(repoRoot string) error {
+	d.aliases = map[string][]string{}
+	return filepath.WalkDir(repoRoot, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Name() == ".git" && entry.IsDir() {
+			return filepath.SkipDir
+		}
+		if entry.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		target, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			return nil // dangling link; nothing to alias
+		}
+		targetRel, err := filepath.Rel(repoRoot, target)
+		if err != nil || strings.HasPrefix(targetRel, "..") {
+			return nil // points outside the repo
+		}
+		linkRel, err := filepath.Rel(repoRoot, p)
+		if err != nil {
+			return nil
+		}
+		targetRel = filepath.ToSlash(targetRel)
+		d.aliases[targetRel] = append(d.aliases[targetRel], filepath.ToSlash(linkRel))
+		return nil
+	})
+}
+
+// expandChanged adds the symlink-side locations of every changed file whose
+// real path sits under a recorded symlink target.
+func (d *Detector) expandChanged(changed []string) []string {
+	if len(d.aliases) == 0 {
+		return changed
+	}
+	out := append([]string(nil), changed...)
+	for _, f := range changed {
+		for target, links := range d.aliases {
+			if !under(f, target) && f != target {
+				continue
+			}
+			rest := strings.TrimPrefix(f, target)
+			for _, link := range links {
+				out = append(out, link+rest)
+			}
+		}
+	}
+	return out
+}
+
+// Affected returns the component paths whose rendered output could change
+// given the changed files. A path is affected when a file changed inside the
+// rendered directory itself, anywhere under the owning component directory
+// (shared bases feed every overlay), or in the ApplicationSet definition
+// that generates the path. Files reachable through recorded symlinks count
+// at both locations.
+func (d *Detector) Affected(changed []string) []appset.ComponentPath {
+	changed = d.expandChanged(changed)
+	mapped := d.mappedComponents(changed)
+	var out []appset.ComponentPath
+	seen := map[string]bool{}
+	for _, p := range d.paths {
+		hit := mapped[p.Component]
+		if !hit {
+			for _, f := range changed {
+				if matches(p, f) || d.matchesDep(p.Path, f) {
+					hit = true
+					break
+				}
+			}
+		}
+		if hit && !seen[p.Path+"|"+p.AppSet] {
+			seen[p.Path+"|"+p.AppSet] = true
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func (d *Detector) matchesDep(path, file string) bool {
+	for _, dep := range d.deps[path] {
+		if under(file, dep) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(p appset.ComponentPath, file string) bool {
+	return under(file, p.Path) || under(file, p.Component) || file == p.File
+}
+
+func under(file, dir string) bool {
+	return dir != "" && (file == dir || strings.HasPrefix(file, dir+"/"))
+}
+
+// Filter narrows paths with include/exclude glob patterns, applied after
+// detection. With include patterns present, a path must match one of them;
+// any exclude match then removes it. Patterns are matched against both the
+// rendered path and the component directory, so `components/monitoring/*`
+// and `components/spi` both do what they look like.
+func Filter(paths []appset.ComponentPath, include, exclude []string) []appset.ComponentPath {
+	var out []appset.ComponentPath
+	for _, p := range paths {
+		if len(include) > 0 && !matchesGlobs(include, p) {
+			continue
+		}
+		if matchesGlobs(exclude, p) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func matchesGlobs(patterns []string, p appset.ComponentPath) bool {
+	for _, pattern := range patterns {
+		for _, candidate := range []string{p.Path, p.Component} {
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+			if pattern == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
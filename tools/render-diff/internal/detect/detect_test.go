@@ -0,0 +1,89 @@
+package detect
+
+import (
+	"testing"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+)
+
+func testPaths() []appset.ComponentPath {
+	return []appset.ComponentPath{
+		{
+			AppSet: "all", Component: "components/spi", Environment: "staging",
+			Path: "components/spi/overlays/staging", File: "argo-cd-apps/all.yaml",
+		},
+		{
+			AppSet: "all", Component: "components/monitoring", Environment: "staging",
+			Path: "components/monitoring/overlays/staging", File: "argo-cd-apps/all.yaml",
+		},
+	}
+}
+
+func TestAffected(t *testing.T) {
+	d := New(testPaths())
+	cases := []struct {
+		changed []string
+		want    int
+	}{
+		// Inside the rendered directory itself.
+		{[]string{"components/spi/overlays/staging/kustomization.yaml"}, 1},
+		// Anywhere under the owning component: shared bases feed overlays.
+		{[]string{"components/spi/base/deployment.yaml"}, 1},
+		// The ApplicationSet definition affects every path it generates.
+		{[]string{"argo-cd-apps/all.yaml"}, 2},
+		// Unrelated files affect nothing.
+		{[]string{"docs/README.md"}, 0},
+	}
+	for _, c := range cases {
+		if got := d.Affected(c.changed); len(got) != c.want {
+			t.Errorf("Affected(%v) = %d paths, want %d: %+v", c.changed, len(got), c.want, got)
+		}
+	}
+}
+
+func TestAffectedWithMappings(t *testing.T) {
+	d := New(testPaths())
+	d.AddMappings([]config.Mapping{{
+		Paths:      []string{"templates/**"},
+		Components: []string{"components/monitoring"},
+	}})
+	got := d.Affected([]string{"templates/alerts/rules.yaml"})
+	if len(got) != 1 || got[0].Component != "components/monitoring" {
+		t.Fatalf("mapping should pull in components/monitoring, got %+v", got)
+	}
+	if got := d.Affected([]string{"templates-other/file.yaml"}); len(got) != 0 {
+		t.Fatalf("non-matching file should not map, got %+v", got)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"templates/**", "templates/a/b.yaml", true},
+		{"templates/**", "templates", true},
+		{"templates/**", "templates-other/b.yaml", false},
+		{"hack/*.sh", "hack/build.sh", true},
+		{"hack/*.sh", "hack/sub/build.sh", false},
+	}
+	for _, c := range cases {
+		if got := matchPattern(c.pattern, c.file); got != c.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", c.pattern, c.file, got, c.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	paths := testPaths()
+	if got := Filter(paths, []string{"components/spi"}, nil); len(got) != 1 || got[0].Component != "components/spi" {
+		t.Fatalf("include by component = %+v", got)
+	}
+	if got := Filter(paths, []string{"components/*/overlays/staging"}, nil); len(got) != 2 {
+		t.Fatalf("include by path glob = %+v", got)
+	}
+	if got := Filter(paths, nil, []string{"components/monitoring"}); len(got) != 1 || got[0].Component != "components/spi" {
+		t.Fatalf("exclude by component = %+v", got)
+	}
+}
@@ -0,0 +1,241 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kustomizationNames are the file names kustomize accepts, in lookup order.
+var kustomizationNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// ScanKustomizeRefs reads the kustomization of every known path and records
+// everything it pulls in from elsewhere in the repository: `components:`
+// entries (kind: Component kustomizations), `resources:`/`bases:` pointing
+// outside the path (this repo's host/member and per-cluster overlays layer
+// shared cluster configs that way) and patch files — all transitively. A
+// change to any shared input then marks every overlay that consumes it as
+// affected.
+func (d *Detector) ScanKustomizeRefs(repoRoot string) error {
+	d.deps = map[string][]string{}
+	d.remotes = map[string]bool{}
+	for _, p := range d.paths {
+		visited := map[string]bool{}
+		collectKustomizeRefs(repoRoot, p.Path, visited, d.remotes)
+		delete(visited, p.Path)
+		for dep := range visited {
+			d.deps[p.Path] = append(d.deps[p.Path], dep)
+		}
+	}
+	return nil
+}
+
+// RemoteRefs returns the remote base references (URL entries) encountered
+// during ScanKustomizeRefs, sorted and deduplicated.
+func (d *Detector) RemoteRefs() []string {
+	refs := make([]string, 0, len(d.remotes))
+	for ref := range d.remotes {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// collectKustomizeRefs records dir and every in-repo file or directory
+// reachable from its kustomization into visited, repo-relative. Remote base
+// references are collected into remotes.
+func collectKustomizeRefs(repoRoot, dir string, visited, remotes map[string]bool) {
+	if visited[dir] {
+		return
+	}
+	visited[dir] = true
+	doc := readKustomization(filepath.Join(repoRoot, dir))
+	if doc == nil {
+		return
+	}
+	var refs []string
+	refs = append(refs, doc.Components...)
+	refs = append(refs, doc.Resources...)
+	refs = append(refs, doc.Bases...)
+	refs = append(refs, doc.PatchesStrategicMerge...)
+	for _, p := range doc.Patches {
+		if p.Path != "" {
+			refs = append(refs, p.Path)
+		}
+	}
+	for _, entry := range refs {
+		if strings.Contains(entry, "://") {
+			remotes[entry] = true
+			continue
+		}
+		ref := filepath.ToSlash(filepath.Join(dir, entry))
+		if strings.HasPrefix(ref, "..") {
+			continue // outside the repo
+		}
+		info, err := os.Stat(filepath.Join(repoRoot, filepath.FromSlash(ref)))
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			collectKustomizeRefs(repoRoot, ref, visited, remotes)
+		} else {
+			visited[ref] = true
+		}
+	}
+}
+
+type kustomization struct {
+	Components            []string `yaml:"components"`
+	Resources             []string `yaml:"resources"`
+	Bases                 []string `yaml:"bases"`
+	PatchesStrategicMerge []string `yaml:"patchesStrategicMerge"`
+	Patches               []struct {
+		Path   string `yaml:"path"`
+		Target struct {
+			Kind string `yaml:"kind"`
+			Name string `yaml:"name"`
+		} `yaml:"target"`
+	} `yaml:"patches"`
+}
+
+// TraceEntry is one source file contributing to a rendered resource.
+type TraceEntry struct {
+	// File is the repo-relative file.
+	File string
+	// Role is how the file contributes: "resource" declares it, "patch"
+	// modifies it, "overlay" is a kustomization layer on the path to it.
+	Role string
+}
+
+// Trace reports which source files contribute to the resource of the given
+// kind and name when dir is built: files declaring it, patches targeting
+// it, and the kustomization layers that pull those in. Name prefixes and
+// suffixes applied by kustomize are not resolved; kind and name must match
+// the source documents.
+func Trace(repoRoot, dir, kind, name string) []TraceEntry {
+	var entries []TraceEntry
+	visited := map[string]bool{}
+	traceDir(repoRoot, dir, kind, name, visited, &entries)
+	return entries
+}
+
+// traceDir recurses through dir's kustomization and reports whether its
+// subtree contributes to the resource, appending entries as it finds them.
+func traceDir(repoRoot, dir, kind, name string, visited map[string]bool, entries *[]TraceEntry) bool {
+	if visited[dir] {
+		return false
+	}
+	visited[dir] = true
+	doc := readKustomization(filepath.Join(repoRoot, dir))
+	if doc == nil {
+		return false
+	}
+	hit := false
+	resolve := func(entry string) (string, os.FileInfo) {
+		ref := filepath.ToSlash(filepath.Join(dir, entry))
+		if strings.HasPrefix(ref, "..") || strings.Contains(entry, "://") {
+			return "", nil
+		}
+		info, err := os.Stat(filepath.Join(repoRoot, filepath.FromSlash(ref)))
+		if err != nil {
+			return "", nil
+		}
+		return ref, info
+	}
+	var refs []string
+	refs = append(refs, doc.Components...)
+	refs = append(refs, doc.Resources...)
+	refs = append(refs, doc.Bases...)
+	for _, entry := range refs {
+		ref, info := resolve(entry)
+		if ref == "" {
+			continue
+		}
+		if info.IsDir() {
+			if traceDir(repoRoot, ref, kind, name, visited, entries) {
+				hit = true
+			}
+		} else if fileDeclares(filepath.Join(repoRoot, ref), kind, name) {
+			*entries = append(*entries, TraceEntry{File: ref, Role: "resource"})
+			hit = true
+		}
+	}
+	for _, entry := range doc.PatchesStrategicMerge {
+		ref, info := resolve(entry)
+		if ref == "" || info.IsDir() {
+			continue
+		}
+		if fileDeclares(filepath.Join(repoRoot, ref), kind, name) {
+			*entries = append(*entries, TraceEntry{File: ref, Role: "patch"})
+			hit = true
+		}
+	}
+	for _, p := range doc.Patches {
+		if p.Path == "" {
+			continue
+		}
+		ref, info := resolve(p.Path)
+		if ref == "" || info.IsDir() {
+			continue
+		}
+		targeted := (p.Target.Kind == "" || p.Target.Kind == kind) && (p.Target.Name == "" || p.Target.Name == name)
+		if p.Target.Kind == "" && p.Target.Name == "" {
+			targeted = fileDeclares(filepath.Join(repoRoot, ref), kind, name)
+		}
+		if targeted {
+			*entries = append(*entries, TraceEntry{File: ref, Role: "patch"})
+			hit = true
+		}
+	}
+	if hit {
+		for _, kname := range kustomizationNames {
+			if _, err := os.Stat(filepath.Join(repoRoot, filepath.FromSlash(dir), kname)); err == nil {
+				*entries = append(*entries, TraceEntry{File: filepath.ToSlash(filepath.Join(dir, kname)), Role: "overlay"})
+				break
+			}
+		}
+	}
+	return hit
+}
+
+// fileDeclares reports whether any document in the file has the given kind
+// and metadata name.
+func fileDeclares(path, kind, name string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		var doc struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+		if err := dec.Decode(&doc); err != nil {
+			return false
+		}
+		if doc.Kind == kind && doc.Metadata.Name == name {
+			return true
+		}
+	}
+}
+
+func readKustomization(dir string) *kustomization {
+	for _, name := range kustomizationNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var doc kustomization
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil
+		}
+		return &doc
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package detect
+
+import (
+	"sort"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+)
+
+// Removal records a component path that exists on base but no longer on
+// head, together with the environments where the component is still
+// rendered — so partial decommissions are visible and intentional.
+type Removal struct {
+	Path appset.ComponentPath `json:"path"`
+	// StillPresentIn lists environments that still render the component
+	// on head; empty means a full decommission.
+	StillPresentIn []string `json:"stillPresentIn,omitempty"`
+}
+
+// Removals compares the component paths discovered on the base and head
+// revisions and reports every path that disappeared.
+func Removals(basePaths, headPaths []appset.ComponentPath) []Removal {
+	headSet := map[string]bool{}
+	envsByComponent := map[string]map[string]bool{}
+	for _, p := range headPaths {
+		headSet[p.Path] = true
+		if p.Environment == "" {
+			continue
+		}
+		if envsByComponent[p.Component] == nil {
+			envsByComponent[p.Component] = map[string]bool{}
+		}
+		envsByComponent[p.Component][p.Environment] = true
+	}
+	var out []Removal
+	seen := map[string]bool{}
+	for _, p := range basePaths {
+		if headSet[p.Path] || seen[p.Path] {
+			continue
+		}
+		seen[p.Path] = true
+		var still []string
+		for env := range envsByComponent[p.Component] {
+			still = append(still, env)
+		}
+		sort.Strings(still)
+		out = append(out, Removal{Path: p, StillPresentIn: still})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path.Path < out[j].Path.Path })
+	return out
+}
@@ -0,0 +1,227 @@
+// Package diffutil normalizes rendered manifests and computes unified diffs
+// between them.
+package diffutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+)
+
+// LooksBinary reports whether rendered content appears to be binary rather
+// than YAML text: a NUL byte or a high share of non-printable bytes in the
+// leading chunk. Broken plugin output is classified up front so megabytes of
+// garbage never reach the differ, comments or terminals.
+func LooksBinary(data []byte) bool {
+	sample := data
+	if len(sample) > 8192 {
+		sample = sample[:8192]
+	}
+	nonPrintable := 0
+	for _, b := range sample {
+		if b == 0 {
+			return true
+		}
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' {
+			nonPrintable++
+		}
+	}
+	return len(sample) > 0 && nonPrintable*100/len(sample) > 10
+}
+
+// Normalize splits a rendered multi-document YAML stream, sorts the
+// documents by apiVersion/kind/namespace/name and re-marshals each one with
+// stable key ordering, so diffs reflect content changes rather than
+// kustomize emission order.
+func Normalize(rendered []byte) (string, error) {
+	var docs []map[string]any
+	dec := yaml.NewDecoder(strings.NewReader(string(rendered)))
+	for {
+		var doc map[string]any
+		if err := dec.Decode(&doc); err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return "", fmt.Errorf("parsing rendered output: %w", err)
+		}
+		if len(doc) > 0 {
+			sortKnownLists(doc)
+			docs = append(docs, doc)
+		}
+	}
+	sort.SliceStable(docs, func(i, j int) bool { return docKey(docs[i]) < docKey(docs[j]) })
+	var b strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return "", err
+		}
+		b.Write(out)
+	}
+	return b.String(), nil
+}
+
+// listMergeKeys maps list field names to their strategic-merge keys, in
+// preference order. Lists keyed here are order-independent for Kubernetes,
+// so sorting them hides pure reorderings (common after kustomize upgrades)
+// while keeping genuine additions and removals visible.
+var listMergeKeys = map[string][]string{
+	"env":              {"name"},
+	"ports":            {"containerPort", "port", "name"},
+	"volumes":          {"name"},
+	"volumeMounts":     {"mountPath", "name"},
+	"envFrom":          {"configMapRef", "secretRef"},
+	"imagePullSecrets": {"name"},
+}
+
+// sortKnownLists recursively sorts lists with known merge keys in place.
+func sortKnownLists(node any) {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if keys, known := listMergeKeys[key]; known {
+				if list, ok := child.([]any); ok {
+					sortByMergeKey(list, keys)
+				}
+			}
+			sortKnownLists(child)
+		}
+	case []any:
+		for _, child := range v {
+			sortKnownLists(child)
+		}
+	}
+}
+
+func sortByMergeKey(list []any, keys []string) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return mergeKeyOf(list[i], keys) < mergeKeyOf(list[j], keys)
+	})
+}
+
+func mergeKeyOf(item any, keys []string) string {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return fmt.Sprintf("%v", item)
+	}
+	for _, k := range keys {
+		if v, found := m[k]; found {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return ""
+}
+
+func docKey(doc map[string]any) string {
+	meta, _ := doc["metadata"].(map[string]any)
+	get := func(m map[string]any, k string) string {
+		s, _ := m[k].(string)
+		return s
+	}
+	return strings.Join([]string{
+		get(doc, "apiVersion"), get(doc, "kind"),
+		get(meta, "namespace"), get(meta, "name"),
+	}, "|")
+}
+
+// largeInputBytes is the combined input size above which the diff is
+// computed externally. difflib holds both line slices plus its edit-graph
+// bookkeeping in memory, which adds up fast when several multi-megabyte
+// renders diff concurrently.
+const largeInputBytes = 4 << 20
+
+// Unified returns a unified diff between base and head, labelled with name.
+// It returns the empty string when the inputs are identical. Very large
+// inputs are streamed to disk and diffed with git to bound peak memory per
+// job.
+func Unified(name, base, head string) (string, error) {
+	if base == head {
+		return "", nil
+	}
+	if len(base)+len(head) > largeInputBytes {
+		if diff, err := externalUnified(name, base, head); err == nil {
+			return diff, nil
+		}
+		// A missing or failing git binary should not fail the job; fall
+		// back to the in-memory differ.
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(base),
+		B:        difflib.SplitLines(head),
+		FromFile: "base/" + name,
+		ToFile:   "head/" + name,
+		Context:  3,
+	})
+}
+
+// externalUnified writes both sides to temporary files and diffs them with
+// "git diff --no-index", which streams rather than materializing the edit
+// graph in memory.
+func externalUnified(name, base, head string) (string, error) {
+	dir, err := os.MkdirTemp("", "render-diff-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	basePath := filepath.Join(dir, "base.yaml")
+	headPath := filepath.Join(dir, "head.yaml")
+	if err := os.WriteFile(basePath, []byte(base), 0o600); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(headPath, []byte(head), 0o600); err != nil {
+		return "", err
+	}
+	out, err := exec.Command("git", "diff", "--no-index", "--no-color", "--unified=3", "--", basePath, headPath).Output()
+	if err != nil {
+		// Exit code 1 just means the files differ.
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", err
+		}
+	}
+	return relabelHeaders(string(out), name), nil
+}
+
+// relabelHeaders drops git's preamble (the "diff --git" and "index" lines)
+// and relabels the file headers with the component name, so the external
+// path produces the same output as the in-memory one.
+func relabelHeaders(out, name string) string {
+	var b strings.Builder
+	inBody := false
+	for _, line := range strings.SplitAfter(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			b.WriteString("--- base/" + name + "\n")
+			inBody = true
+		case strings.HasPrefix(line, "+++ "):
+			b.WriteString("+++ head/" + name + "\n")
+		case inBody:
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// Stats counts added and removed lines in a unified diff.
+func Stats(diff string) (added, removed int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
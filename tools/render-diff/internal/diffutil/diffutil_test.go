@@ -0,0 +1,106 @@
+package diffutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSortsDocumentsAndKeys(t *testing.T) {
+	rendered := []byte(`kind: Service
+metadata:
+  name: svc
+apiVersion: v1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+`)
+	out, err := Normalize(rendered)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	cm := strings.Index(out, "kind: ConfigMap")
+	svc := strings.Index(out, "kind: Service")
+	if cm < 0 || svc < 0 || cm > svc {
+		t.Fatalf("documents not sorted by kind:\n%s", out)
+	}
+	if !strings.HasPrefix(out, "apiVersion: v1\nkind: ConfigMap\n") {
+		t.Fatalf("keys not re-marshalled in stable order:\n%s", out)
+	}
+	if !strings.Contains(out, "---\n") {
+		t.Fatalf("documents not separated:\n%s", out)
+	}
+}
+
+func TestNormalizeHidesListReorderings(t *testing.T) {
+	a := []byte(`kind: Pod
+spec:
+  containers:
+    - env:
+        - name: B
+          value: "2"
+        - name: A
+          value: "1"
+`)
+	b := []byte(`kind: Pod
+spec:
+  containers:
+    - env:
+        - name: A
+          value: "1"
+        - name: B
+          value: "2"
+`)
+	na, err := Normalize(a)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	nb, err := Normalize(b)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if na != nb {
+		t.Fatalf("env reordering should normalize away:\n%s\nvs\n%s", na, nb)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	if diff, err := Unified("x", "same\n", "same\n"); err != nil || diff != "" {
+		t.Fatalf("identical inputs: diff=%q err=%v", diff, err)
+	}
+	diff, err := Unified("components/spi", "a\nb\n", "a\nc\n")
+	if err != nil {
+		t.Fatalf("Unified: %v", err)
+	}
+	for _, want := range []string{"--- base/components/spi", "+++ head/components/spi", "-b", "+c"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	diff := strings.Join([]string{
+		"--- base/x",
+		"+++ head/x",
+		"@@ -1,2 +1,2 @@",
+		" context",
+		"-old",
+		"+new",
+		"+extra",
+	}, "\n")
+	added, removed := Stats(diff)
+	if added != 2 || removed != 1 {
+		t.Fatalf("Stats = +%d -%d, want +2 -1", added, removed)
+	}
+}
+
+func TestLooksBinary(t *testing.T) {
+	if LooksBinary([]byte("kind: ConfigMap\n")) {
+		t.Error("plain YAML classified as binary")
+	}
+	if !LooksBinary([]byte("abc\x00def")) {
+		t.Error("NUL byte not classified as binary")
+	}
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/agekey"
+)
+
+// sandboxProxyEnv points every proxy-aware fetcher (git over https, helm,
+// plain http clients) at an unroutable endpoint, as a fallback network
+// sandbox on hosts without unshare(1).
+var sandboxProxyEnv = []string{
+	"HTTP_PROXY=http://127.0.0.1:1",
+	"HTTPS_PROXY=http://127.0.0.1:1",
+	"http_proxy=http://127.0.0.1:1",
+	"https_proxy=http://127.0.0.1:1",
+	"NO_PROXY=",
+	"no_proxy=",
+}
+
+// SandboxProxyEnv exposes the proxy-poisoning entries for callers that
+// sandbox the whole process instead of individual build commands.
+func SandboxProxyEnv() []string {
+	return append([]string(nil), sandboxProxyEnv...)
+}
+
+// RepoBuilder renders a single component directory of a repository checkout
+// into a manifest stream.
+type RepoBuilder interface {
+	// Build renders the component at the repo-relative path inside root.
+	Build(ctx context.Context, root, path string) ([]byte, error)
+}
+
+// ExecBuilder shells out to the kustomize binary.
+type ExecBuilder struct {
+	// Bin is the kustomize binary to run; "kustomize" on PATH when empty.
+	Bin string
+	// Env holds extra environment variables (KEY=VALUE) passed to builds,
+	// e.g. SOPS_AGE_KEY for decrypting plugins.
+	Env []string
+	// EnableHelm inflates helmCharts entries during builds.
+	EnableHelm bool
+	// HelmBin is the helm binary used for inflation; "helm" when empty.
+	HelmBin string
+	// EnablePlugins allows KRM functions and exec plugins, restricted to
+	// PluginAllow; see VerifyPlugins.
+	EnablePlugins bool
+	PluginAllow   []string
+	// LoadRestrictor overrides kustomize's file load restriction, e.g.
+	// "LoadRestrictionsNone" for cross-directory references.
+	LoadRestrictor string
+	// ExtraFlags are passed verbatim to every kustomize build invocation.
+	ExtraFlags []string
+	// NoNetwork runs each build without network access — in a private
+	// network namespace via unshare(1) when available, otherwise behind
+	// unroutable proxy variables — so a component fetching remote content
+	// fails loudly instead of silently depending on the network.
+	NoNetwork bool
+}
+
+func (b *ExecBuilder) Build(ctx context.Context, root, path string) ([]byte, error) {
+	bin := b.Bin
+	if bin == "" {
+		bin = "kustomize"
+	}
+	args := []string{"build", path}
+	if b.EnableHelm {
+		args = append(args, "--enable-helm")
+		if b.HelmBin != "" {
+			args = append(args, "--helm-command", b.HelmBin)
+		}
+	}
+	if b.EnablePlugins {
+		if err := VerifyPlugins(root, path, b.PluginAllow); err != nil {
+			return nil, err
+		}
+		args = append(args, "--enable-alpha-plugins", "--enable-exec")
+	}
+	if b.LoadRestrictor != "" {
+		args = append(args, "--load-restrictor", b.LoadRestrictor)
+	}
+	args = append(args, b.ExtraFlags...)
+	cmd := exec.CommandContext(ctx, bin, args...)
+	env := b.Env
+	if b.NoNetwork {
+		if _, err := exec.LookPath("unshare"); err == nil {
+			cmd = exec.CommandContext(ctx, "unshare", append([]string{"--net", "--map-current-user", bin}, args...)...)
+		} else {
+			env = append(append([]string(nil), env...), sandboxProxyEnv...)
+		}
+	}
+	cmd.Dir = root
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Plugin output can echo secrets; scrub key material before
+		// the error travels into comments and logs.
+		return nil, fmt.Errorf("kustomize build %s: %w: %s", path, err, agekey.Redact(strings.TrimSpace(stderr.String())))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,480 @@
+// Package engine renders component paths on both sides of a comparison and
+// computes the diffs between the rendered outputs.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/diffutil"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/envsubst"
+)
+
+// Job is one render-and-diff unit of work.
+type Job struct {
+	Path appset.ComponentPath
+}
+
+// ComponentDiff is the outcome of one Job.
+type ComponentDiff struct {
+	Path    appset.ComponentPath `json:"path"`
+	Diff    string               `json:"diff,omitempty"`
+	Added   int                  `json:"added"`
+	Removed int                  `json:"removed"`
+	// Deleted marks a component directory that exists on base but was
+	// removed on head; the diff is then a full removal.
+	Deleted bool `json:"deleted,omitempty"`
+	// Error holds a build or diff failure for this component.
+	Error string `json:"error,omitempty"`
+	// HeadRender is the normalized head-side render, retained only when
+	// Engine.KeepRenders is set for post-run analysis (policy checks).
+	HeadRender string `json:"-"`
+	// BuildMillis and DiffMillis record how long this component's builds
+	// and its diff took. Memoized and cached builds report near zero.
+	BuildMillis int64 `json:"buildMillis,omitempty"`
+	DiffMillis  int64 `json:"diffMillis,omitempty"`
+}
+
+// DiffResult is the aggregate outcome of a run.
+type DiffResult struct {
+	BaseRef    string          `json:"baseRef"`
+	BaseSHA    string          `json:"baseSHA"`
+	HeadSHA    string          `json:"headSHA"`
+	Components []ComponentDiff `json:"components"`
+	// Removals lists component paths present on base but gone on head.
+	Removals []detect.Removal `json:"removals,omitempty"`
+	// Findings are analysis warnings attached after diffing (capacity,
+	// policy and similar checks).
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Finding is one analysis warning about the rendered change.
+type Finding struct {
+	// Severity is "info", "warning" or "critical".
+	Severity string `json:"severity"`
+	// Category groups findings, e.g. "capacity".
+	Category string `json:"category"`
+	// Component is the affected rendered path, when attributable.
+	Component string `json:"component,omitempty"`
+	Message   string `json:"message"`
+}
+
+// HasDiffs reports whether any component produced a non-empty diff.
+func (r *DiffResult) HasDiffs() bool {
+	for _, c := range r.Components {
+		if c.Diff != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasBuildErrors reports whether any component failed to build.
+func (r *DiffResult) HasBuildErrors() bool {
+	for _, c := range r.Components {
+		if c.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Engine renders jobs concurrently against a base and a head checkout.
+type Engine struct {
+	Builder RepoBuilder
+	// Concurrency bounds parallel builds; when zero it is chosen
+	// adaptively from the CPU count, load average and available memory.
+	Concurrency int
+	// Vars holds per-environment variables substituted for ${VAR}
+	// placeholders in rendered output on both sides.
+	Vars map[string]map[string]string
+	// BuildTimeout bounds each component build, so one hanging build (bad
+	// remote base, stuck plugin) cannot stall the whole run. Zero means no
+	// limit.
+	BuildTimeout time.Duration
+	// MemoryAware throttles concurrency down to one job at a time while
+	// the host is low on memory; see memoryGate.
+	MemoryAware bool
+	// KeepRenders retains each job's head-side render on its
+	// ComponentDiff for post-run analysis.
+	KeepRenders bool
+	// IgnoreRules, when set, supplies Argo CD ignoreDifferences rules per
+	// component; covered fields are stripped from both sides before
+	// diffing.
+	IgnoreRules func(path appset.ComponentPath) []appset.IgnoreRule
+	// BaselineDir, when set, replaces base-side builds with stored renders
+	// from a render-all snapshot tree (one <path>.yaml file per component
+	// path); baseRoot is then never read.
+	BaselineDir string
+	// RenderCache, when set, persists normalized renders across runs keyed
+	// by the content hash of the component's input tree. Callers whose
+	// builder configuration changes the output (helm, plugins, extra flags)
+	// must namespace the cache accordingly.
+	RenderCache RenderCache
+	// OnProgress, when set, receives a snapshot each time a job starts or
+	// finishes — decoupled from the results channel, which only carries
+	// jobs that produced output. Calls are serialized and must not block.
+	OnProgress func(Progress)
+
+	// builds memoizes renders within a run: the same directory generated
+	// for several ApplicationSets or environments builds once per side.
+	builds buildCache
+	// hashes memoizes TreeHash per root and path within a run.
+	hashes sync.Map
+}
+
+// treeHash memoizes TreeHash so a path hashed for the pre-build skip is not
+// re-hashed for the build memo key.
+func (e *Engine) treeHash(root, path string) (string, error) {
+	type outcome struct {
+		hash string
+		err  error
+	}
+	key := root + "\x00" + path
+	if v, ok := e.hashes.Load(key); ok {
+		o := v.(outcome)
+		return o.hash, o.err
+	}
+	hash, err := TreeHash(root, path)
+	e.hashes.Store(key, outcome{hash, err})
+	return hash, err
+}
+
+// Progress is one snapshot of a run's job accounting.
+type Progress struct {
+	Queued  int
+	Running int
+	Done    int
+	// Component is the path whose state just changed.
+	Component string
+}
+
+// progressTracker serializes job accounting updates into OnProgress calls.
+type progressTracker struct {
+	mu       sync.Mutex
+	progress Progress
+	notify   func(Progress)
+}
+
+func (t *progressTracker) start(component string) {
+	t.mu.Lock()
+	t.progress.Queued--
+	t.progress.Running++
+	t.progress.Component = component
+	snapshot := t.progress
+	t.mu.Unlock()
+	t.notify(snapshot)
+}
+
+func (t *progressTracker) finish(component string) {
+	t.mu.Lock()
+	t.progress.Running--
+	t.progress.Done++
+	t.progress.Component = component
+	snapshot := t.progress
+	t.mu.Unlock()
+	t.notify(snapshot)
+}
+
+// RenderCache stores normalized renders keyed by input-tree content hash.
+// Implementations are best effort: a miss or failed store only costs a
+// rebuild.
+type RenderCache interface {
+	Get(key string) (render string, ok bool)
+	Put(key, render string)
+}
+
+// buildCache deduplicates concurrent renders of the same root and path.
+type buildCache struct {
+	mu      sync.Mutex
+	entries map[string]*buildEntry
+}
+
+type buildEntry struct {
+	once sync.Once
+	out  string
+	err  error
+}
+
+func (c *buildCache) entry(key string) *buildEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*buildEntry{}
+	}
+	e, ok := c.entries[key]
+	if !ok {
+		e = &buildEntry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+// RunProgressive starts all jobs and streams each ComponentDiff as it
+// completes. The channel is closed once every job has finished.
+func (e *Engine) RunProgressive(ctx context.Context, baseRoot, headRoot string, jobs []Job) <-chan ComponentDiff {
+	workers := e.Concurrency
+	if workers <= 0 {
+		workers = adaptiveConcurrency()
+	}
+	// Schedule cheap jobs first: collected results are sorted by path at
+	// the end anyway, and front-loading small components streams most
+	// results out quickly while the few giant ones finish last.
+	jobs = sortBySize(baseRoot, headRoot, jobs)
+	queue := make(chan Job)
+	results := make(chan ComponentDiff)
+	var gate *memoryGate
+	if e.MemoryAware {
+		gate = &memoryGate{}
+	}
+	var tracker *progressTracker
+	if e.OnProgress != nil {
+		tracker = &progressTracker{progress: Progress{Queued: len(jobs)}, notify: e.OnProgress}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if gate != nil {
+					if gate.acquire(ctx) != nil {
+						continue
+					}
+				}
+				if tracker != nil {
+					tracker.start(job.Path.Path)
+				}
+				diff, ok := e.runJob(ctx, baseRoot, headRoot, job)
+				if tracker != nil {
+					tracker.finish(job.Path.Path)
+				}
+				if gate != nil {
+					gate.release()
+				}
+				if ok {
+					results <- diff
+				}
+			}
+		}()
+	}
+	go func() {
+		for _, job := range jobs {
+			select {
+			case queue <- job:
+			case <-ctx.Done():
+			}
+		}
+		close(queue)
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// sortBySize orders jobs by the byte size of their component directory, a
+// cheap stand-in for build cost. The head side is measured, falling back to
+// base for deleted components.
+func sortBySize(baseRoot, headRoot string, jobs []Job) []Job {
+	sorted := append([]Job(nil), jobs...)
+	sizes := make(map[string]int64, len(sorted))
+	for _, job := range sorted {
+		size := dirSize(filepath.Join(headRoot, job.Path.Path))
+		if size == 0 {
+			size = dirSize(filepath.Join(baseRoot, job.Path.Path))
+		}
+		sizes[job.Path.Path] = size
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sizes[sorted[i].Path.Path] < sizes[sorted[j].Path.Path]
+	})
+	return sorted
+}
+
+// dirSize sums regular file sizes under dir; 0 when it does not exist.
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// Run collects RunProgressive into a DiffResult, sorted by path.
+func (e *Engine) Run(ctx context.Context, baseRoot, headRoot string, jobs []Job) (*DiffResult, error) {
+	result := &DiffResult{}
+	for diff := range e.RunProgressive(ctx, baseRoot, headRoot, jobs) {
+		result.Components = append(result.Components, diff)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(result.Components, func(i, j int) bool {
+		return result.Components[i].Path.Path < result.Components[j].Path.Path
+	})
+	return result, nil
+}
+
+func (e *Engine) runJob(ctx context.Context, baseRoot, headRoot string, job Job) (ComponentDiff, bool) {
+	path := job.Path.Path
+	deleted := false
+	if _, err := os.Stat(filepath.Join(headRoot, path)); err != nil {
+		onBase := false
+		if e.BaselineDir != "" {
+			_, berr := os.Stat(filepath.Join(e.BaselineDir, path+".yaml"))
+			onBase = berr == nil
+		} else {
+			_, berr := os.Stat(filepath.Join(baseRoot, path))
+			onBase = berr == nil
+		}
+		if !onBase {
+			slog.Info("component missing on both sides, skipping", "path", path)
+			return ComponentDiff{}, false
+		}
+		// Present on base, gone on head: diff the base render against
+		// nothing so the removal is fully visible.
+		deleted = true
+	}
+	// Identical input trees cannot produce a diff: when base and head hash
+	// the same, skip both builds outright. Coarse change detection routes
+	// jobs here whose inputs did not actually change, and this is where
+	// that redundancy dies. Post-run analysis still needs the head render,
+	// so the skip is off under KeepRenders.
+	if !deleted && e.BaselineDir == "" && !e.KeepRenders {
+		if baseHash, err := e.treeHash(baseRoot, path); err == nil {
+			if headHash, err := e.treeHash(headRoot, path); err == nil && baseHash == headHash {
+				return ComponentDiff{Path: job.Path}, true
+			}
+		}
+	}
+	// Build failures stay in the result as structured errors so every
+	// output mode (and the exit code) can surface them; a silently skipped
+	// component would read as "no changes".
+	buildStart := time.Now()
+	var base string
+	var err error
+	if e.BaselineDir != "" {
+		base, err = e.readBaseline(path)
+	} else {
+		base, err = e.buildSide(ctx, baseRoot, path)
+	}
+	if err != nil {
+		slog.Error("base build failed", "path", path, "error", err)
+		return ComponentDiff{Path: job.Path, Error: fmt.Sprintf("base: %v", err)}, true
+	}
+	head, err := e.buildSide(ctx, headRoot, path)
+	if err != nil {
+		slog.Error("head build failed", "path", path, "error", err)
+		return ComponentDiff{Path: job.Path, Error: fmt.Sprintf("head: %v", err)}, true
+	}
+	buildMillis := time.Since(buildStart).Milliseconds()
+	if vars := e.Vars[job.Path.Environment]; len(vars) > 0 {
+		base = envsubst.Expand(base, vars)
+		head = envsubst.Expand(head, vars)
+	}
+	if e.IgnoreRules != nil {
+		if rules := e.IgnoreRules(job.Path); len(rules) > 0 {
+			if base, err = applyIgnores(base, rules); err != nil {
+				return ComponentDiff{Path: job.Path, Error: err.Error()}, true
+			}
+			if head, err = applyIgnores(head, rules); err != nil {
+				return ComponentDiff{Path: job.Path, Error: err.Error()}, true
+			}
+		}
+	}
+	diffStart := time.Now()
+	diff, err := diffutil.Unified(path, base, head)
+	if err != nil {
+		return ComponentDiff{Path: job.Path, Error: err.Error()}, true
+	}
+	added, removed := diffutil.Stats(diff)
+	out := ComponentDiff{
+		Path: job.Path, Diff: diff, Added: added, Removed: removed, Deleted: deleted,
+		BuildMillis: buildMillis, DiffMillis: time.Since(diffStart).Milliseconds(),
+	}
+	if e.KeepRenders {
+		out.HeadRender = head
+	}
+	return out, true
+}
+
+// readBaseline loads a component's stored render from the baseline
+// snapshot. A missing file means the component did not exist at the
+// baseline, so it diffs as new.
+func (e *Engine) readBaseline(path string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(e.BaselineDir, path+".yaml"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// buildSide renders one side of the comparison, memoized within the run by
+// the content hash of the component's input tree — so identical base and
+// head sides, and identical directories under different roots, build once.
+// When a hash cannot be computed the memo key falls back to root and path.
+// A path that does not exist on the base side renders as empty, so newly
+// added components diff cleanly against nothing.
+func (e *Engine) buildSide(ctx context.Context, root, path string) (string, error) {
+	key := root + "\x00" + path
+	hash := ""
+	if h, err := e.treeHash(root, path); err == nil {
+		hash = h
+		key = "sha256\x00" + h
+	}
+	entry := e.builds.entry(key)
+	entry.once.Do(func() {
+		if hash != "" && e.RenderCache != nil {
+			if out, ok := e.RenderCache.Get(hash); ok {
+				entry.out = out
+				return
+			}
+		}
+		entry.out, entry.err = e.buildSideUncached(ctx, root, path)
+		if hash != "" && e.RenderCache != nil && entry.err == nil {
+			e.RenderCache.Put(hash, entry.out)
+		}
+	})
+	return entry.out, entry.err
+}
+
+func (e *Engine) buildSideUncached(ctx context.Context, root, path string) (string, error) {
+	if _, err := os.Stat(filepath.Join(root, path)); err != nil {
+		return "", nil
+	}
+	buildCtx := ctx
+	if e.BuildTimeout > 0 {
+		var cancel context.CancelFunc
+		buildCtx, cancel = context.WithTimeout(ctx, e.BuildTimeout)
+		defer cancel()
+	}
+	rendered, err := e.Builder.Build(buildCtx, root, path)
+	if err != nil {
+		if buildCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return "", fmt.Errorf("build timed out after %s", e.BuildTimeout)
+		}
+		return "", err
+	}
+	if diffutil.LooksBinary(rendered) {
+		return "", fmt.Errorf("kustomize build %s emitted binary or non-YAML content (%d bytes); check the component's generators and plugins", path, len(rendered))
+	}
+	return diffutil.Normalize(rendered)
+}
@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TreeHash computes a sha256 over a component's effective input set: every
+// file under its directory plus every local file or directory its
+// kustomization chain references outside of it. Two trees with the same
+// hash render identically, so the hash can key render caches and skip
+// redundant builds. Remote bases are hashed by reference only — their URL
+// pins the content well enough for ?ref= style pins, which is all this
+// repo uses.
+func TreeHash(root, path string) (string, error) {
+	h := sha256.New()
+	visited := map[string]bool{}
+	if err := hashTree(h, root, filepath.Clean(path), visited); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashTree feeds one directory tree into h and recurses into local
+// kustomization references that point outside it.
+func hashTree(h io.Writer, root, rel string, visited map[string]bool) error {
+	if visited[rel] {
+		return nil
+	}
+	visited[rel] = true
+	dir := filepath.Join(root, rel)
+	var refs []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fileRel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			visited[fileRel] = true
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(fileRel), len(data))
+		h.Write(data)
+		if isKustomizationFile(d.Name()) {
+			for _, ref := range kustomizationRefs(data) {
+				refs = append(refs, resolveRef(filepath.Dir(fileRel), ref))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		if ref == "" || visited[ref] {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(root, ref))
+		if err != nil {
+			// Dangling references still change the render (kustomize fails),
+			// so fold the miss into the hash rather than erroring here.
+			fmt.Fprintf(h, "missing\x00%s\x00", ref)
+			continue
+		}
+		if info.IsDir() {
+			if err := hashTree(h, root, ref, visited); err != nil {
+				return err
+			}
+			continue
+		}
+		visited[ref] = true
+		data, err := os.ReadFile(filepath.Join(root, ref))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(ref), len(data))
+		h.Write(data)
+	}
+	return nil
+}
+
+func isKustomizationFile(name string) bool {
+	switch name {
+	case "kustomization.yaml", "kustomization.yml", "Kustomization":
+		return true
+	}
+	return false
+}
+
+// kustomizationRefs extracts the local file and directory references from a
+// kustomization document. Remote references (anything with a scheme) are
+// hashed where they appear in the file and not followed.
+func kustomizationRefs(data []byte) []string {
+	var k struct {
+		Resources             []string `yaml:"resources"`
+		Bases                 []string `yaml:"bases"`
+		Components            []string `yaml:"components"`
+		Crds                  []string `yaml:"crds"`
+		PatchesStrategicMerge []string `yaml:"patchesStrategicMerge"`
+		Patches               []struct {
+			Path string `yaml:"path"`
+		} `yaml:"patches"`
+		PatchesJson6902 []struct {
+			Path string `yaml:"path"`
+		} `yaml:"patchesJson6902"`
+		ConfigMapGenerator []generatorRef `yaml:"configMapGenerator"`
+		SecretGenerator    []generatorRef `yaml:"secretGenerator"`
+	}
+	if yaml.Unmarshal(data, &k) != nil {
+		return nil
+	}
+	refs := append([]string{}, k.Resources...)
+	refs = append(refs, k.Bases...)
+	refs = append(refs, k.Components...)
+	refs = append(refs, k.Crds...)
+	refs = append(refs, k.PatchesStrategicMerge...)
+	for _, p := range k.Patches {
+		refs = append(refs, p.Path)
+	}
+	for _, p := range k.PatchesJson6902 {
+		refs = append(refs, p.Path)
+	}
+	for _, g := range append(k.ConfigMapGenerator, k.SecretGenerator...) {
+		for _, f := range g.Files {
+			// files entries may be "key=path".
+			if _, file, found := strings.Cut(f, "="); found {
+				f = file
+			}
+			refs = append(refs, f)
+		}
+		refs = append(refs, g.Envs...)
+		if g.Env != "" {
+			refs = append(refs, g.Env)
+		}
+	}
+	local := refs[:0]
+	for _, ref := range refs {
+		if ref == "" || strings.Contains(ref, "://") {
+			continue
+		}
+		local = append(local, ref)
+	}
+	return local
+}
+
+type generatorRef struct {
+	Files []string `yaml:"files"`
+	Envs  []string `yaml:"envs"`
+	Env   string   `yaml:"env"`
+}
+
+// resolveRef turns a reference relative to the kustomization's directory
+// into a repo-relative path, or "" when it escapes the checkout.
+func resolveRef(dir, ref string) string {
+	rel := filepath.Clean(filepath.Join(dir, ref))
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return rel
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+)
+
+// applyIgnores strips the fields covered by Argo CD ignoreDifferences
+// rules from a normalized render, so the diff only shows changes Argo CD
+// would act on. Both sides of a diff go through the same rules, keeping
+// the comparison symmetric.
+func applyIgnores(render string, rules []appset.IgnoreRule) (string, error) {
+	var docs []map[string]any
+	dec := yaml.NewDecoder(strings.NewReader(render))
+	for {
+		var doc map[string]any
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		for _, rule := range rules {
+			if !ruleMatches(rule, doc) {
+				continue
+			}
+			for _, pointer := range rule.JSONPointers {
+				deletePointer(doc, pointer)
+			}
+		}
+		docs = append(docs, doc)
+	}
+	var b strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return "", err
+		}
+		b.Write(out)
+	}
+	return b.String(), nil
+}
+
+// ruleMatches applies Argo CD's matching semantics: kind is required,
+// group/name/namespace constrain the rule only when set.
+func ruleMatches(rule appset.IgnoreRule, doc map[string]any) bool {
+	kind, _ := doc["kind"].(string)
+	if rule.Kind != "" && rule.Kind != kind {
+		return false
+	}
+	apiVersion, _ := doc["apiVersion"].(string)
+	group, _, found := strings.Cut(apiVersion, "/")
+	if !found {
+		group = ""
+	}
+	if rule.Group != "" && rule.Group != group {
+		return false
+	}
+	meta, _ := doc["metadata"].(map[string]any)
+	name, _ := meta["name"].(string)
+	if rule.Name != "" && rule.Name != name {
+		return false
+	}
+	namespace, _ := meta["namespace"].(string)
+	if rule.Namespace != "" && rule.Namespace != namespace {
+		return false
+	}
+	return true
+}
+
+// deletePointer removes the value at a JSON pointer from doc. Pointers
+// that do not resolve are ignored; list elements are nulled rather than
+// spliced out so sibling indices stay stable.
+func deletePointer(doc map[string]any, pointer string) {
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	var node any = doc
+	for i, token := range tokens {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		last := i == len(tokens)-1
+		switch v := node.(type) {
+		case map[string]any:
+			if last {
+				delete(v, token)
+				return
+			}
+			node = v[token]
+		case []any:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return
+			}
+			if last {
+				v[idx] = nil
+				return
+			}
+			node = v[idx]
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+)
+
+const ignoreRender = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: spi
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: main
+        - name: sidecar
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: spi-config
+data:
+  replicas: keep-me
+`
+
+func TestApplyIgnoresStripsMatchedPointer(t *testing.T) {
+	rules := []appset.IgnoreRule{{
+		Group:        "apps",
+		Kind:         "Deployment",
+		JSONPointers: []string{"/spec/replicas"},
+	}}
+	out, err := applyIgnores(ignoreRender, rules)
+	if err != nil {
+		t.Fatalf("applyIgnores: %v", err)
+	}
+	if strings.Contains(out, "replicas: 3") {
+		t.Fatalf("replicas should be stripped from the Deployment:\n%s", out)
+	}
+	// The rule is scoped to Deployments; the ConfigMap keeps its key.
+	if !strings.Contains(out, "replicas: keep-me") {
+		t.Fatalf("ConfigMap data must be untouched:\n%s", out)
+	}
+}
+
+func TestApplyIgnoresNullsListElements(t *testing.T) {
+	rules := []appset.IgnoreRule{{
+		Kind:         "Deployment",
+		JSONPointers: []string{"/spec/template/spec/containers/0"},
+	}}
+	out, err := applyIgnores(ignoreRender, rules)
+	if err != nil {
+		t.Fatalf("applyIgnores: %v", err)
+	}
+	if strings.Contains(out, "name: main") {
+		t.Fatalf("first container should be nulled:\n%s", out)
+	}
+	// Elements are nulled, not spliced, so the sibling keeps its index.
+	if !strings.Contains(out, "name: sidecar") {
+		t.Fatalf("second container must survive:\n%s", out)
+	}
+}
+
+func TestApplyIgnoresUnresolvedPointer(t *testing.T) {
+	rules := []appset.IgnoreRule{{
+		Kind:         "Deployment",
+		JSONPointers: []string{"/spec/does/not/exist"},
+	}}
+	out, err := applyIgnores(ignoreRender, rules)
+	if err != nil {
+		t.Fatalf("applyIgnores: %v", err)
+	}
+	if !strings.Contains(out, "replicas: 3") {
+		t.Fatalf("unresolved pointers must leave the doc alone:\n%s", out)
+	}
+}
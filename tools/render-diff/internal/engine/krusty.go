@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/agekey"
+)
+
+// KrustyBuilder renders kustomizations in process through the kustomize Go
+// API. It removes the external binary dependency — the kustomize version is
+// pinned by go.mod — and avoids spawning a process per component, which adds
+// up when a run renders hundreds of paths.
+type KrustyBuilder struct {
+	// EnableHelm inflates helmCharts entries during builds instead of
+	// failing on them; chart pulls need network access.
+	EnableHelm bool
+	// HelmBin is the helm binary used for inflation; "helm" when empty.
+	HelmBin string
+	// EnablePlugins allows KRM functions and exec plugins, restricted to
+	// PluginAllow; see VerifyPlugins.
+	EnablePlugins bool
+	PluginAllow   []string
+	// LoadRestrictor overrides kustomize's file load restriction, e.g.
+	// "LoadRestrictionsNone" for cross-directory references.
+	LoadRestrictor string
+}
+
+func (b *KrustyBuilder) Build(ctx context.Context, root, path string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	buildOpts := krusty.MakeDefaultOptions()
+	if b.EnableHelm {
+		command := b.HelmBin
+		if command == "" {
+			command = "helm"
+		}
+		buildOpts.PluginConfig.HelmConfig = types.HelmConfig{Enabled: true, Command: command}
+	}
+	if b.EnablePlugins {
+		if err := VerifyPlugins(root, path, b.PluginAllow); err != nil {
+			return nil, err
+		}
+		buildOpts.PluginConfig.PluginRestrictions = types.PluginRestrictionsNone
+		buildOpts.PluginConfig.FnpLoadingOptions.EnableExec = true
+	}
+	switch b.LoadRestrictor {
+	case "", "LoadRestrictionsRootOnly":
+	case "LoadRestrictionsNone":
+		buildOpts.LoadRestrictions = types.LoadRestrictionsNone
+	default:
+		return nil, fmt.Errorf("unknown load restrictor %q", b.LoadRestrictor)
+	}
+	kustomizer := krusty.MakeKustomizer(buildOpts)
+	resMap, err := kustomizer.Run(filesys.MakeFsOnDisk(), filepath.Join(root, path))
+	if err != nil {
+		// Generator output can echo secrets; scrub key material before
+		// the error travels into comments and logs.
+		return nil, fmt.Errorf("kustomize build %s: %s", path, agekey.Redact(err.Error()))
+	}
+	return resMap.AsYaml()
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// adaptiveConcurrency picks a worker count for the host at hand: NumCPU as
+// the ceiling, scaled down when the load average already saturates the CPUs
+// or when available memory cannot fit that many concurrent builds. Small CI
+// runners and busy laptops land on something sustainable instead of NumCPU.
+func adaptiveConcurrency() int {
+	workers := runtime.NumCPU()
+	if load, ok := loadAverage(); ok {
+		if idle := runtime.NumCPU() - int(load+0.5); idle < workers {
+			workers = idle
+		}
+	}
+	if available, _, ok := readMeminfo(); ok {
+		// Budget half a GiB per concurrent build, the high end of what
+		// kustomize needs on this repo's largest components.
+		if byMemory := int(available / (512 << 20)); byMemory < workers {
+			workers = byMemory
+		}
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// loadAverage returns the 1-minute load average from /proc/loadavg; ok is
+// false on platforms without it.
+func loadAverage() (float64, bool) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return load, true
+}
+
+// memoryGate throttles parallel builds when the host is running low on
+// memory. Workers acquire it before each job; under pressure only one job
+// runs at a time until memory frees up, which keeps constrained CI runners
+// from being OOM-killed mid-run. At least one job is always allowed through
+// so the run makes progress.
+type memoryGate struct {
+	mu       sync.Mutex
+	inflight int
+}
+
+func (g *memoryGate) acquire(ctx context.Context) error {
+	warned := false
+	for {
+		g.mu.Lock()
+		if g.inflight == 0 || !memoryPressure() {
+			g.inflight++
+			g.mu.Unlock()
+			return nil
+		}
+		g.mu.Unlock()
+		if !warned {
+			slog.Warn("memory pressure detected, throttling build concurrency")
+			warned = true
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+func (g *memoryGate) release() {
+	g.mu.Lock()
+	g.inflight--
+	g.mu.Unlock()
+}
+
+// memoryPressure reports whether available memory has dropped below a
+// safety floor: 10% of total, but at least 256 MiB. On platforms without
+// /proc/meminfo it always reports false.
+func memoryPressure() bool {
+	available, total, ok := readMeminfo()
+	if !ok {
+		return false
+	}
+	floor := total / 10
+	if floor < 256<<20 {
+		floor = 256 << 20
+	}
+	return available < floor
+}
+
+// readMeminfo returns MemAvailable and MemTotal in bytes from /proc/meminfo.
+func readMeminfo() (available, total uint64, ok bool) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, 0, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemAvailable:":
+			available = kb << 10
+		case "MemTotal:":
+			total = kb << 10
+		}
+	}
+	return available, total, available > 0 && total > 0
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	gopath "path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VerifyPlugins walks the manifests under path in root and returns an error
+// when one declares a KRM function or exec plugin that is not covered by the
+// allowlist, so CI never executes arbitrary code pulled in by a PR.
+func VerifyPlugins(root, path string, allow []string) error {
+	dir := filepath.Join(root, path)
+	return filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if ext := filepath.Ext(entry.Name()); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		for _, ref := range pluginRefs(data) {
+			if !allowed(ref, allow) {
+				rel, _ := filepath.Rel(root, p)
+				return fmt.Errorf("plugin %q in %s is not in the plugins.allow list", ref, rel)
+			}
+		}
+		return nil
+	})
+}
+
+// pluginRefs extracts the function references (container images and exec
+// paths) from the config.kubernetes.io/function annotations in a manifest.
+func pluginRefs(data []byte) []string {
+	var refs []string
+	for _, doc := range strings.Split(string(data), "\n---") {
+		var manifest struct {
+			Metadata struct {
+				Annotations map[string]string `yaml:"annotations"`
+			} `yaml:"metadata"`
+		}
+		if yaml.Unmarshal([]byte(doc), &manifest) != nil {
+			continue
+		}
+		fn := manifest.Metadata.Annotations["config.kubernetes.io/function"]
+		if fn == "" {
+			continue
+		}
+		var spec struct {
+			Container struct {
+				Image string `yaml:"image"`
+			} `yaml:"container"`
+			Exec struct {
+				Path string `yaml:"path"`
+			} `yaml:"exec"`
+		}
+		if yaml.Unmarshal([]byte(fn), &spec) != nil {
+			continue
+		}
+		if spec.Container.Image != "" {
+			refs = append(refs, spec.Container.Image)
+		}
+		if spec.Exec.Path != "" {
+			refs = append(refs, spec.Exec.Path)
+		}
+	}
+	return refs
+}
+
+func allowed(ref string, allow []string) bool {
+	for _, pattern := range allow {
+		if pattern == ref {
+			return true
+		}
+		if ok, _ := gopath.Match(pattern, ref); ok {
+			return true
+		}
+	}
+	return false
+}
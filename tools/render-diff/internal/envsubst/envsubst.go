@@ -0,0 +1,64 @@
+// Package envsubst expands ${VAR} placeholders in rendered manifests from
+// per-environment variable files, so diffs reflect what actually gets
+// applied instead of raw placeholders.
+package envsubst
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadVars reads a variable file of KEY=VALUE lines. Blank lines and lines
+// starting with # are ignored.
+func LoadVars(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE, got %q", path, i+1, line)
+		}
+		vars[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return vars, nil
+}
+
+// Expand replaces ${VAR} occurrences with their values from vars. Unknown
+// variables are left untouched so missing entries stay visible in diffs,
+// and bare $VAR references are not expanded to avoid mangling shell
+// snippets embedded in manifests.
+func Expand(s string, vars map[string]string) string {
+	if len(vars) == 0 || !strings.Contains(s, "${") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			break
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:start])
+		name := s[start+2 : start+end]
+		if value, ok := vars[name]; ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(s[start : start+end+1])
+		}
+		s = s[start+end+1:]
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package envsubst
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadVars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vars.env")
+	content := "# comment\n\nHOST=example.com\nPORT = 8080\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vars, err := LoadVars(path)
+	if err != nil {
+		t.Fatalf("LoadVars: %v", err)
+	}
+	if vars["HOST"] != "example.com" || vars["PORT"] != "8080" {
+		t.Fatalf("LoadVars = %v", vars)
+	}
+}
+
+func TestLoadVarsRejectsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vars.env")
+	if err := os.WriteFile(path, []byte("JUSTAKEY\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadVars(path); err == nil {
+		t.Fatal("expected error for line without =")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{"HOST": "example.com"}
+	cases := []struct{ in, want string }{
+		{"url: https://${HOST}/api", "url: https://example.com/api"},
+		{"unknown: ${MISSING}", "unknown: ${MISSING}"},
+		{"shell: $HOST", "shell: $HOST"},
+		{"no placeholders", "no placeholders"},
+		{"broken: ${HOST", "broken: ${HOST"},
+	}
+	for _, c := range cases {
+		if got := Expand(c.in, vars); got != c.want {
+			t.Errorf("Expand(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
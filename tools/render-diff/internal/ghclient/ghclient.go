@@ -0,0 +1,303 @@
+// Package ghclient wraps the GitHub operations render-diff performs: keeping
+// a single marker-tagged comment up to date on a pull request and maintaining
+// tracking issues for reports.
+package ghclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+	"golang.org/x/oauth2"
+)
+
+// Client talks to a single GitHub repository.
+type Client struct {
+	gh    *github.Client
+	http  *http.Client
+	owner string
+	repo  string
+}
+
+// New returns a Client for ownerRepo ("owner/repo") authenticated with token.
+func New(ctx context.Context, token, ownerRepo string) (*Client, error) {
+	owner, repo, found := strings.Cut(ownerRepo, "/")
+	if !found || owner == "" || repo == "" {
+		return nil, fmt.Errorf("invalid repository %q, expected owner/repo", ownerRepo)
+	}
+	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	httpClient.Transport = newRetryTransport(httpClient.Transport)
+	// Cap each request (including retries of it) so a hung connection
+	// cannot stall CI; callers still control the overall deadline via ctx.
+	httpClient.Timeout = 5 * time.Minute
+	return &Client{gh: github.NewClient(httpClient), http: httpClient, owner: owner, repo: repo}, nil
+}
+
+// UpsertComment creates or updates the comment on pr that carries marker.
+// The marker is embedded in the body so re-runs find and edit the same
+// comment instead of stacking new ones.
+func (c *Client) UpsertComment(ctx context.Context, pr int, marker, body string) error {
+	body = marker + "\n" + body
+	existing, err := c.findComment(ctx, pr, marker)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		// An edit bumps the comment's timestamp and triggers
+		// notifications, so skip it when nothing changed. GitHub stores
+		// CRLF line endings, hence the normalization.
+		if normalizeBody(existing.GetBody()) == normalizeBody(body) {
+			return nil
+		}
+		_, _, err = c.gh.Issues.EditComment(ctx, c.owner, c.repo, existing.GetID(), &github.IssueComment{Body: &body})
+		return err
+	}
+	_, _, err = c.gh.Issues.CreateComment(ctx, c.owner, c.repo, pr, &github.IssueComment{Body: &body})
+	return err
+}
+
+// UpdateCommentIfExists edits the marker comment when one is already
+// present and does nothing otherwise. Used to replace a stale change table
+// once a later push clears the differences, without adding a comment to PRs
+// that never had one.
+func (c *Client) UpdateCommentIfExists(ctx context.Context, pr int, marker, body string) error {
+	existing, err := c.findComment(ctx, pr, marker)
+	if err != nil || existing == nil {
+		return err
+	}
+	body = marker + "\n" + body
+	if normalizeBody(existing.GetBody()) == normalizeBody(body) {
+		return nil
+	}
+	_, _, err = c.gh.Issues.EditComment(ctx, c.owner, c.repo, existing.GetID(), &github.IssueComment{Body: &body})
+	return err
+}
+
+func normalizeBody(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+}
+
+func (c *Client) findComment(ctx context.Context, pr int, marker string) (*github.IssueComment, error) {
+	// GraphQL pages comments with bodies in one round-trip; fall back to
+	// the REST pagination loop if the query fails (e.g. token lacks the
+	// GraphQL scope).
+	if c.http != nil {
+		id, body, found, err := c.findCommentGraphQL(ctx, pr, marker)
+		if err == nil {
+			if !found {
+				return nil, nil
+			}
+			return &github.IssueComment{ID: &id, Body: &body}, nil
+		}
+		slog.Debug("graphql comment lookup failed, falling back to REST", "error", err)
+	}
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.gh.Issues.ListComments(ctx, c.owner, c.repo, pr, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.GetBody(), marker) {
+				return comment, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ChecklistComplete reports whether the marker comment on pr exists and has
+// every markdown checkbox ticked. A missing comment counts as complete,
+// since there is nothing left to approve.
+func (c *Client) ChecklistComplete(ctx context.Context, pr int, marker string) (bool, error) {
+	comment, err := c.findComment(ctx, pr, marker)
+	if err != nil {
+		return false, err
+	}
+	if comment == nil {
+		return true, nil
+	}
+	return !strings.Contains(comment.GetBody(), "- [ ]"), nil
+}
+
+// SyncLabels reconciles the given labels on pr: every label mapped to true
+// is applied, every label mapped to false is removed if present. Labels not
+// mentioned in desired are left alone, so this can own a label namespace
+// without touching human-applied labels.
+func (c *Client) SyncLabels(ctx context.Context, pr int, desired map[string]bool) error {
+	have := map[string]bool{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		labels, resp, err := c.gh.Issues.ListLabelsByIssue(ctx, c.owner, c.repo, pr, opts)
+		if err != nil {
+			return err
+		}
+		for _, l := range labels {
+			have[l.GetName()] = true
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	var add []string
+	for label, want := range desired {
+		switch {
+		case want && !have[label]:
+			add = append(add, label)
+		case !want && have[label]:
+			if _, err := c.gh.Issues.RemoveLabelForIssue(ctx, c.owner, c.repo, pr, label); err != nil {
+				return err
+			}
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+	_, _, err := c.gh.Issues.AddLabelsToIssue(ctx, c.owner, c.repo, pr, add)
+	return err
+}
+
+// Acknowledged reports whether the pull request carries an explicit
+// render-diff approval signal: the given label, or an issue comment whose
+// body starts with "/render-diff approve".
+func (c *Client) Acknowledged(ctx context.Context, pr int, label string) (bool, error) {
+	if label != "" {
+		if has, err := c.HasLabel(ctx, pr, label); err != nil || has {
+			return has, err
+		}
+	}
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.gh.Issues.ListComments(ctx, c.owner, c.repo, pr, opts)
+		if err != nil {
+			return false, err
+		}
+		for _, comment := range comments {
+			if strings.HasPrefix(strings.TrimSpace(comment.GetBody()), "/render-diff approve") {
+				return true, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// HasLabel reports whether the pull request currently carries the label.
+func (c *Client) HasLabel(ctx context.Context, pr int, name string) (bool, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		labels, resp, err := c.gh.Issues.ListLabelsByIssue(ctx, c.owner, c.repo, pr, opts)
+		if err != nil {
+			return false, err
+		}
+		for _, l := range labels {
+			if l.GetName() == name {
+				return true, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// UpsertBodySection maintains the region between begin and end markers in
+// the PR description, appending the section when the markers are absent.
+// Content outside the markers is preserved untouched.
+func (c *Client) UpsertBodySection(ctx context.Context, pr int, begin, end, content string) error {
+	pull, _, err := c.gh.PullRequests.Get(ctx, c.owner, c.repo, pr)
+	if err != nil {
+		return err
+	}
+	body := normalizeBody(pull.GetBody())
+	section := begin + "\n" + content + "\n" + end
+	var updated string
+	before, rest, foundBegin := strings.Cut(body, begin)
+	if foundBegin {
+		_, after, foundEnd := strings.Cut(rest, end)
+		if !foundEnd {
+			after = ""
+		}
+		updated = strings.TrimRight(before, "\n") + "\n\n" + section + after
+	} else {
+		updated = body + "\n\n" + section
+	}
+	updated = strings.TrimSpace(updated)
+	if updated == body {
+		return nil
+	}
+	_, _, err = c.gh.PullRequests.Edit(ctx, c.owner, c.repo, pr, &github.PullRequest{Body: &updated})
+	return err
+}
+
+// RequestReviewers asks the given users and teams for review on pr. The API
+// already ignores reviewers that are assigned or authored the PR.
+func (c *Client) RequestReviewers(ctx context.Context, pr int, users, teams []string) error {
+	if len(users) == 0 && len(teams) == 0 {
+		return nil
+	}
+	_, _, err := c.gh.PullRequests.RequestReviewers(ctx, c.owner, c.repo, pr, github.ReviewersRequest{
+		Reviewers:     users,
+		TeamReviewers: teams,
+	})
+	return err
+}
+
+// SetStatus creates or updates a commit status on sha.
+func (c *Client) SetStatus(ctx context.Context, sha, state, statusContext, description string) error {
+	_, _, err := c.gh.Repositories.CreateStatus(ctx, c.owner, c.repo, sha, &github.RepoStatus{
+		State:       &state,
+		Context:     &statusContext,
+		Description: &description,
+	})
+	return err
+}
+
+// UpsertIssue creates or updates an open tracking issue identified by marker
+// in its body, returning the issue's HTML URL.
+func (c *Client) UpsertIssue(ctx context.Context, title, marker, body string, labels []string) (string, error) {
+	body = marker + "\n" + body
+	opts := &github.IssueListByRepoOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		issues, resp, err := c.gh.Issues.ListByRepo(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, issue := range issues {
+			if strings.Contains(issue.GetBody(), marker) {
+				updated, _, err := c.gh.Issues.Edit(ctx, c.owner, c.repo, issue.GetNumber(), &github.IssueRequest{
+					Title: &title,
+					Body:  &body,
+				})
+				if err != nil {
+					return "", err
+				}
+				return updated.GetHTMLURL(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	created, _, err := c.gh.Issues.Create(ctx, c.owner, c.repo, &github.IssueRequest{
+		Title:  &title,
+		Body:   &body,
+		Labels: &labels,
+	})
+	if err != nil {
+		return "", err
+	}
+	return created.GetHTMLURL(), nil
+}
@@ -0,0 +1,95 @@
+package ghclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const graphqlEndpoint = "https://api.github.com/graphql"
+
+// graphql posts one GraphQL query and decodes the data field into out.
+func (c *Client) graphql(ctx context.Context, query string, vars map[string]any, out any) error {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, graphqlEndpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("graphql: %s", resp.Status)
+	}
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	if len(envelope.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", envelope.Errors[0].Message)
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+// findCommentGraphQL locates the marker comment through the GraphQL API.
+// One round-trip returns a hundred comments with their bodies, where the
+// REST path needs a request per page, which matters on PRs with long
+// comment histories.
+func (c *Client) findCommentGraphQL(ctx context.Context, pr int, marker string) (id int64, body string, found bool, err error) {
+	const query = `query($owner: String!, $repo: String!, $pr: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $pr) {
+      comments(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes { databaseId body }
+      }
+    }
+  }
+}`
+	vars := map[string]any{"owner": c.owner, "repo": c.repo, "pr": pr, "cursor": nil}
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					Comments struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							DatabaseID int64  `json:"databaseId"`
+							Body       string `json:"body"`
+						} `json:"nodes"`
+					} `json:"comments"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := c.graphql(ctx, query, vars, &data); err != nil {
+			return 0, "", false, err
+		}
+		comments := data.Repository.PullRequest.Comments
+		for _, node := range comments.Nodes {
+			if strings.Contains(node.Body, marker) {
+				return node.DatabaseID, node.Body, true, nil
+			}
+		}
+		if !comments.PageInfo.HasNextPage {
+			return 0, "", false, nil
+		}
+		vars["cursor"] = comments.PageInfo.EndCursor
+	}
+}
@@ -0,0 +1,97 @@
+package ghclient
+
+import (
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryTransport retries transient GitHub API failures so a single network
+// blip or secondary rate limit does not fail the whole comment mode in CI.
+// Server errors and rate-limit responses are retried with exponential
+// backoff, honoring a Retry-After header when the API sends one.
+type retryTransport struct {
+	base     http.RoundTripper
+	attempts int
+	backoff  time.Duration
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, attempts: 4, backoff: time.Second}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	delay := t.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err = t.base.RoundTrip(req)
+		if attempt == t.attempts || !retryable(resp, err) || req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+		wait := delay + time.Duration(rand.Int63n(int64(delay/2+1)))
+		if resp != nil {
+			if after := retryAfter(resp); after > 0 {
+				wait = after
+			}
+			resp.Body.Close()
+		}
+		slog.Warn("retrying GitHub request", "method", req.Method, "url", req.URL.Path, "attempt", attempt, "wait", wait)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+		delay *= 2
+	}
+}
+
+// retryable reports whether a response (or transport error) is worth another
+// attempt: network errors, server errors, and primary or secondary rate
+// limits. Other client errors are permanent.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		// Secondary rate limits come back as 403 with Retry-After or an
+		// exhausted primary quota; a plain 403 is a permissions problem.
+		return resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-Ratelimit-Remaining") == "0"
+	default:
+		return resp.StatusCode >= 500
+	}
+}
+
+// retryAfter extracts the API-mandated wait from a rate-limit response,
+// capped so a long reset window does not stall CI indefinitely.
+func retryAfter(resp *http.Response) time.Duration {
+	const maxWait = 2 * time.Minute
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return min(time.Duration(secs)*time.Second, maxWait)
+		}
+	}
+	if v := resp.Header.Get("X-Ratelimit-Reset"); v != "" {
+		if epoch, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if wait := time.Until(time.Unix(epoch, 0)); wait > 0 {
+				return min(wait, maxWait)
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,277 @@
+// Package git provides the small set of git operations render-diff needs.
+// Operations are expressed through the Client interface so the backing
+// implementation can be swapped out.
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Client is the git surface used by the rest of the tool.
+type Client interface {
+	// TopLevel returns the absolute path of the repository root.
+	TopLevel(ctx context.Context) (string, error)
+	// ResolveRef resolves a ref (branch, tag, SHA expression) to a full SHA.
+	ResolveRef(ctx context.Context, ref string) (string, error)
+	// MergeBase returns the merge base of two commits.
+	MergeBase(ctx context.Context, a, b string) (string, error)
+	// ChangedFiles lists repo-relative paths that differ between base and
+	// head. An empty head compares base against the working tree,
+	// including unstaged edits.
+	ChangedFiles(ctx context.Context, base, head string) ([]string, error)
+	// CreateWorktree checks ref out into dir as a detached worktree and
+	// returns a cleanup function that removes the worktree again.
+	CreateWorktree(ctx context.Context, dir, ref string) (func() error, error)
+	// Commit stages the given repo-relative paths and commits them.
+	Commit(ctx context.Context, message string, paths ...string) error
+	// Fetch retrieves refspecs from remote; deepen > 0 additionally
+	// deepens a shallow clone by that many commits.
+	Fetch(ctx context.Context, remote string, refspecs []string, deepen int) error
+	// UntrackedFiles lists files in the working tree that are not yet
+	// tracked by git, honoring ignore rules.
+	UntrackedFiles(ctx context.Context) ([]string, error)
+	// CreateSparseWorktree is CreateWorktree restricted to the given
+	// repo-relative paths, cutting checkout time and disk usage on large
+	// repositories.
+	CreateSparseWorktree(ctx context.Context, dir, ref string, paths []string) (func() error, error)
+	// DefaultBranch returns the remote default branch ref (e.g.
+	// "origin/main"), as recorded in origin/HEAD.
+	DefaultBranch(ctx context.Context) (string, error)
+	// Commits lists the commits reachable from head but not from base,
+	// oldest first.
+	Commits(ctx context.Context, base, head string) ([]string, error)
+}
+
+// DefaultBaseRef returns the ref render-diff should diff against when the
+// caller did not pick one: the remote default branch when it can be
+// determined, falling back to whichever of origin/main and origin/master
+// exists. Forks tracking master or release branches thus work without
+// --base-ref.
+func DefaultBaseRef(ctx context.Context, c Client) string {
+	if ref, err := c.DefaultBranch(ctx); err == nil && ref != "" {
+		return ref
+	}
+	for _, ref := range []string{"origin/main", "origin/master"} {
+		if _, err := c.ResolveRef(ctx, ref); err == nil {
+			return ref
+		}
+	}
+	return "origin/main"
+}
+
+// MergeBaseWithFetch computes the merge base of baseRef and head, fetching
+// the base ref from origin and progressively deepening shallow clones when
+// the merge base cannot be computed locally — the common case in shallow CI
+// checkouts.
+func MergeBaseWithFetch(ctx context.Context, c Client, baseRef, head string) (string, error) {
+	sha, err := c.MergeBase(ctx, baseRef, head)
+	if err == nil {
+		return sha, nil
+	}
+	refspec := strings.TrimPrefix(baseRef, "origin/")
+	if ferr := c.Fetch(ctx, "origin", []string{refspec + ":" + baseRef}, 0); ferr != nil {
+		slog.Debug("base ref fetch failed", "ref", refspec, "error", ferr)
+	}
+	for deepen := 64; deepen <= 4096; deepen *= 4 {
+		if sha, err = c.MergeBase(ctx, baseRef, head); err == nil {
+			return sha, nil
+		}
+		slog.Info("deepening shallow history to find merge base", "deepen", deepen)
+		if ferr := c.Fetch(ctx, "origin", nil, deepen); ferr != nil {
+			break
+		}
+	}
+	return c.MergeBase(ctx, baseRef, head)
+}
+
+// New returns a Client for the repository at repoDir. The git binary on
+// PATH is preferred; when it is absent (minimal containers) a pure-Go
+// go-git implementation is used instead.
+func New(repoDir string) Client {
+	if _, err := exec.LookPath("git"); err != nil {
+		if c, gerr := newGogitClient(repoDir); gerr == nil {
+			slog.Debug("git binary not found, using go-git", "repo", repoDir)
+			return c
+		}
+		// Fall through: the cliClient will surface a clear exec error.
+	}
+	return &cliClient{repoDir: repoDir}
+}
+
+// cliClient shells out to the git binary on PATH.
+type cliClient struct {
+	repoDir string
+}
+
+func (c *cliClient) run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = c.repoDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (c *cliClient) TopLevel(ctx context.Context) (string, error) {
+	return c.run(ctx, "rev-parse", "--show-toplevel")
+}
+
+func (c *cliClient) ResolveRef(ctx context.Context, ref string) (string, error) {
+	return c.run(ctx, "rev-parse", "--verify", ref+"^{commit}")
+}
+
+func (c *cliClient) DefaultBranch(ctx context.Context) (string, error) {
+	// origin/HEAD is set by clone; repositories added via `git remote add`
+	// may lack it, in which case DefaultBaseRef falls back to probing.
+	out, err := c.run(ctx, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func (c *cliClient) MergeBase(ctx context.Context, a, b string) (string, error) {
+	out, err := c.run(ctx, "merge-base", a, b)
+	if err != nil {
+		return "", fmt.Errorf("could not compute merge-base of %s and %s: %w", a, b, err)
+	}
+	return out, nil
+}
+
+func (c *cliClient) ChangedFiles(ctx context.Context, base, head string) ([]string, error) {
+	args := []string{"diff", "--name-only", base}
+	if head != "" {
+		args = append(args, head)
+	}
+	out, err := c.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func (c *cliClient) UntrackedFiles(ctx context.Context) ([]string, error) {
+	out, err := c.run(ctx, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func (c *cliClient) Fetch(ctx context.Context, remote string, refspecs []string, deepen int) error {
+	args := []string{"fetch"}
+	if deepen > 0 {
+		args = append(args, fmt.Sprintf("--deepen=%d", deepen))
+	}
+	args = append(args, remote)
+	args = append(args, refspecs...)
+	_, err := c.run(ctx, args...)
+	return err
+}
+
+func (c *cliClient) Commits(ctx context.Context, base, head string) ([]string, error) {
+	out, err := c.run(ctx, "rev-list", "--reverse", base+".."+head)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func (c *cliClient) Commit(ctx context.Context, message string, paths ...string) error {
+	if _, err := c.run(ctx, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	_, err := c.run(ctx, append([]string{"commit", "-m", message, "--"}, paths...)...)
+	return err
+}
+
+func (c *cliClient) CreateWorktree(ctx context.Context, dir, ref string) (func() error, error) {
+	if _, err := c.run(ctx, "worktree", "add", "--detach", dir, ref); err != nil {
+		return nil, err
+	}
+	if err := c.initSubmodules(ctx, dir); err != nil {
+		slog.Warn("submodule init failed in worktree", "dir", dir, "error", err)
+	}
+	c.smudgeLFS(ctx, dir)
+	return c.worktreeCleanup(dir), nil
+}
+
+// smudgeLFS materializes LFS-tracked files inside a freshly created
+// worktree. When the repository uses LFS but the client is not installed it
+// warns once instead of letting kustomize read pointer files.
+func (c *cliClient) smudgeLFS(ctx context.Context, dir string) {
+	data, err := os.ReadFile(filepath.Join(dir, ".gitattributes"))
+	if err != nil || !strings.Contains(string(data), "filter=lfs") {
+		return
+	}
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		slog.Warn("repository uses git-lfs but the client is not installed; LFS-tracked manifests will render as pointer files", "dir", dir)
+		return
+	}
+	inTree := &cliClient{repoDir: dir}
+	if _, err := inTree.run(ctx, "lfs", "checkout"); err != nil {
+		slog.Warn("git lfs checkout failed", "dir", dir, "error", err)
+	}
+}
+
+// initSubmodules initializes submodules inside a freshly created worktree,
+// so kustomizations referencing submodule content render on both refs.
+// Without a .gitmodules file this is a no-op.
+func (c *cliClient) initSubmodules(ctx context.Context, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".gitmodules")); err != nil {
+		return nil
+	}
+	inTree := &cliClient{repoDir: dir}
+	_, err := inTree.run(ctx, "submodule", "update", "--init", "--recursive")
+	return err
+}
+
+func (c *cliClient) CreateSparseWorktree(ctx context.Context, dir, ref string, paths []string) (func() error, error) {
+	if len(paths) == 0 {
+		return c.CreateWorktree(ctx, dir, ref)
+	}
+	if _, err := c.run(ctx, "worktree", "add", "--detach", "--no-checkout", dir, ref); err != nil {
+		return nil, err
+	}
+	cleanup := c.worktreeCleanup(dir)
+	inTree := &cliClient{repoDir: dir}
+	if _, err := inTree.run(ctx, append([]string{"sparse-checkout", "set"}, paths...)...); err != nil {
+		cleanup()
+		return nil, err
+	}
+	if _, err := inTree.run(ctx, "checkout", "--detach", ref); err != nil {
+		cleanup()
+		return nil, err
+	}
+	if err := c.initSubmodules(ctx, dir); err != nil {
+		slog.Warn("submodule init failed in worktree", "dir", dir, "error", err)
+	}
+	c.smudgeLFS(ctx, dir)
+	return cleanup, nil
+}
+
+func (c *cliClient) worktreeCleanup(dir string) func() error {
+	return func() error {
+		_, err := c.run(context.Background(), "worktree", "remove", "--force", dir)
+		return err
+	}
+}
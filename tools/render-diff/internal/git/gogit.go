@@ -0,0 +1,285 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	gogitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// gogitClient implements Client with go-git, for environments (minimal
+// containers) that do not ship a git binary. It is selected automatically by
+// New when git is absent from PATH.
+type gogitClient struct {
+	repoDir string
+	repo    *gogit.Repository
+}
+
+func newGogitClient(repoDir string) (*gogitClient, error) {
+	repo, err := gogit.PlainOpenWithOptions(repoDir, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening repository at %s: %w", repoDir, err)
+	}
+	return &gogitClient{repoDir: repoDir, repo: repo}, nil
+}
+
+func (c *gogitClient) TopLevel(ctx context.Context) (string, error) {
+	wt, err := c.repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(wt.Filesystem.Root())
+}
+
+func (c *gogitClient) ResolveRef(ctx context.Context, ref string) (string, error) {
+	hash, err := c.repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return hash.String(), nil
+}
+
+func (c *gogitClient) DefaultBranch(ctx context.Context) (string, error) {
+	ref, err := c.repo.Reference(plumbing.ReferenceName("refs/remotes/origin/HEAD"), false)
+	if err != nil {
+		return "", fmt.Errorf("origin/HEAD not set: %w", err)
+	}
+	target := ref.Target().String()
+	return "origin/" + strings.TrimPrefix(strings.TrimPrefix(target, "refs/remotes/origin/"), "refs/heads/"), nil
+}
+
+func (c *gogitClient) MergeBase(ctx context.Context, a, b string) (string, error) {
+	commitA, err := c.commit(a)
+	if err != nil {
+		return "", err
+	}
+	commitB, err := c.commit(b)
+	if err != nil {
+		return "", err
+	}
+	bases, err := commitA.MergeBase(commitB)
+	if err != nil || len(bases) == 0 {
+		return "", fmt.Errorf("could not compute merge-base of %s and %s: %w", a, b, err)
+	}
+	return bases[0].Hash.String(), nil
+}
+
+func (c *gogitClient) ChangedFiles(ctx context.Context, base, head string) ([]string, error) {
+	if head == "" {
+		return c.changedAgainstWorktree(base)
+	}
+	baseTree, err := c.tree(base)
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := c.tree(head)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTreeWithOptions(ctx, baseTree, headTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	seen := map[string]bool{}
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				files = append(files, name)
+			}
+		}
+	}
+	return files, nil
+}
+
+// CreateWorktree materializes ref's tree under dir. go-git has no linked
+// worktree support, so the tree contents are written out file by file; the
+// cleanup function removes the directory.
+func (c *gogitClient) CreateWorktree(ctx context.Context, dir, ref string) (func() error, error) {
+	return c.materialize(ctx, dir, ref, nil)
+}
+
+func (c *gogitClient) CreateSparseWorktree(ctx context.Context, dir, ref string, paths []string) (func() error, error) {
+	return c.materialize(ctx, dir, ref, paths)
+}
+
+// materialize writes ref's tree under dir, restricted to the given path
+// prefixes when any are supplied.
+func (c *gogitClient) materialize(ctx context.Context, dir, ref string, only []string) (func() error, error) {
+	tree, err := c.tree(ref)
+	if err != nil {
+		return nil, err
+	}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(only) > 0 && !hasPrefixAny(f.Name, only) {
+			return nil
+		}
+		target := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		mode := os.FileMode(0o644)
+		if f.Mode.IsFile() {
+			if osMode, err := f.Mode.ToOSFileMode(); err == nil {
+				mode = osMode
+			}
+		}
+		return os.WriteFile(target, []byte(contents), mode)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("materializing %s into %s: %w", ref, dir, err)
+	}
+	cleanup := func() error { return os.RemoveAll(dir) }
+	return cleanup, nil
+}
+
+func (c *gogitClient) Fetch(ctx context.Context, remote string, refspecs []string, deepen int) error {
+	opts := &gogit.FetchOptions{RemoteName: remote}
+	for _, rs := range refspecs {
+		opts.RefSpecs = append(opts.RefSpecs, gogitconfig.RefSpec(rs))
+	}
+	if deepen > 0 {
+		// go-git has no --deepen equivalent; fetching with a larger
+		// absolute depth has the same effect for our purposes.
+		opts.Depth = deepen
+	}
+	err := c.repo.FetchContext(ctx, opts)
+	if err == gogit.NoErrAlreadyUpToDate {
+		return nil
+	}
+	return err
+}
+
+func (c *gogitClient) Commits(ctx context.Context, base, head string) ([]string, error) {
+	baseHash, err := c.repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", base, err)
+	}
+	headHash, err := c.repo.ResolveRevision(plumbing.Revision(head))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", head, err)
+	}
+	iter, err := c.repo.Log(&gogit.LogOptions{From: *headHash})
+	if err != nil {
+		return nil, err
+	}
+	var shas []string
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == *baseHash {
+			return storer.ErrStop
+		}
+		shas = append(shas, commit.Hash.String())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Log walks newest first; callers want oldest first.
+	for i, j := 0, len(shas)-1; i < j; i, j = i+1, j-1 {
+		shas[i], shas[j] = shas[j], shas[i]
+	}
+	return shas, nil
+}
+
+func (c *gogitClient) Commit(ctx context.Context, message string, paths ...string) error {
+	wt, err := c.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if _, err := wt.Add(p); err != nil {
+			return err
+		}
+	}
+	_, err = wt.Commit(message, &gogit.CommitOptions{})
+	return err
+}
+
+func (c *gogitClient) UntrackedFiles(ctx context.Context) ([]string, error) {
+	wt, err := c.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for name, st := range status {
+		if st.Worktree == gogit.Untracked {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// changedAgainstWorktree combines base..HEAD changes with dirty and staged
+// files from the working tree.
+func (c *gogitClient) changedAgainstWorktree(base string) ([]string, error) {
+	files, err := c.ChangedFiles(context.Background(), base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	wt, err := c.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, f := range files {
+		seen[f] = true
+	}
+	for name, st := range status {
+		if st.Worktree == gogit.Unmodified && st.Staging == gogit.Unmodified {
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+func hasPrefixAny(name string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *gogitClient) commit(ref string) (*object.Commit, error) {
+	hash, err := c.repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return c.repo.CommitObject(*hash)
+}
+
+func (c *gogitClient) tree(ref string) (*object.Tree, error) {
+	commit, err := c.commit(ref)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
@@ -0,0 +1,87 @@
+// Package history persists one compact record per render-diff run so later
+// invocations can reason about trends across pull requests.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ComponentRecord summarizes one component's outcome in a run.
+type ComponentRecord struct {
+	Component   string `json:"component"`
+	Environment string `json:"environment,omitempty"`
+	ClusterDir  string `json:"clusterDir,omitempty"`
+	Added       int    `json:"added"`
+	Removed     int    `json:"removed"`
+	HasDiff     bool   `json:"hasDiff"`
+}
+
+// Entry is the stored record of a single run.
+type Entry struct {
+	PR         int               `json:"pr,omitempty"`
+	HeadSHA    string            `json:"headSHA"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	Components []ComponentRecord `json:"components"`
+}
+
+// Store is an append-only store of run entries, one JSON document per line
+// in runs.jsonl under its directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a Store rooted at dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) file() string {
+	return filepath.Join(s.dir, "runs.jsonl")
+}
+
+// Append records one entry.
+func (s *Store) Append(e Entry) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.file(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Entries reads every stored entry in insertion order. A missing store reads
+// as empty.
+func (s *Store) Entries() ([]Entry, error) {
+	f, err := os.Open(s.file())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("corrupt history entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
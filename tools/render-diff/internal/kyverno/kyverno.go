@@ -0,0 +1,77 @@
+// Package kyverno runs Kyverno policy checks against rendered manifests by
+// shelling out to the kyverno CLI, as an alternative policy engine to Rego.
+// Policies are plain ClusterPolicy files kept in a repo directory.
+package kyverno
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// FindingsFor applies the policies under policyDir to every head render
+// with `kyverno apply` and turns failed applies into critical findings.
+// Requires the engine to have run with KeepRenders.
+func FindingsFor(ctx context.Context, bin, policyDir string, result *engine.DiffResult) ([]engine.Finding, error) {
+	if bin == "" {
+		bin = "kyverno"
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		return nil, fmt.Errorf("kyverno binary %q not found: %w", bin, err)
+	}
+	if _, err := os.Stat(policyDir); err != nil {
+		return nil, fmt.Errorf("kyverno policy directory %s: %w", policyDir, err)
+	}
+	var findings []engine.Finding
+	for _, c := range result.Components {
+		if c.HeadRender == "" {
+			continue
+		}
+		resource, err := os.CreateTemp("", "render-diff-kyverno-*.yaml")
+		if err != nil {
+			return nil, err
+		}
+		_, werr := resource.WriteString(c.HeadRender)
+		resource.Close()
+		if werr != nil {
+			os.Remove(resource.Name())
+			return nil, werr
+		}
+		cmd := exec.CommandContext(ctx, bin, "apply", policyDir, "--resource", resource.Name())
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err = cmd.Run()
+		os.Remove(resource.Name())
+		if err == nil {
+			continue
+		}
+		findings = append(findings, engine.Finding{
+			Severity:  "critical",
+			Category:  "kyverno",
+			Component: c.Path.Path,
+			Message:   lastLines(out.String(), 5),
+		})
+	}
+	return findings, nil
+}
+
+// lastLines trims command output to its final n non-empty lines, where
+// kyverno summarizes the failed rules.
+func lastLines(s string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
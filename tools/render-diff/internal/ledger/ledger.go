@@ -0,0 +1,69 @@
+// Package ledger maintains an append-only, git-committed audit trail of
+// merged render deltas, one compact JSON record per line. Keeping it in the
+// repository makes the history survive CI retention limits.
+package ledger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// ComponentSummary is the per-component slice of a ledger record.
+type ComponentSummary struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Error   bool   `json:"error,omitempty"`
+}
+
+// Record summarizes one merged change.
+type Record struct {
+	SHA        string             `json:"sha"`
+	RecordedAt time.Time          `json:"recordedAt"`
+	Components []ComponentSummary `json:"components,omitempty"`
+	Findings   []string           `json:"findings,omitempty"`
+}
+
+// FromResult condenses a DiffResult into a Record, keeping only components
+// that actually changed.
+func FromResult(result *engine.DiffResult) Record {
+	r := Record{SHA: result.HeadSHA, RecordedAt: time.Now().UTC()}
+	for _, c := range result.Components {
+		if c.Diff == "" && c.Error == "" {
+			continue
+		}
+		r.Components = append(r.Components, ComponentSummary{
+			Path:    c.Path.Path,
+			Added:   c.Added,
+			Removed: c.Removed,
+			Error:   c.Error != "",
+		})
+	}
+	for _, f := range result.Findings {
+		r.Findings = append(r.Findings, f.Category+": "+f.Message)
+	}
+	return r
+}
+
+// Append writes the record as one JSON line at the end of path, creating
+// parent directories as needed.
+func Append(path string, r Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,217 @@
+// Package lint checks kustomization directories for hygiene problems that
+// kustomize tolerates but that bite later in review: dangling references,
+// unreferenced files, duplicate resources and non-deterministic
+// generators.
+package lint
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is one lint finding about a kustomization directory.
+type Issue struct {
+	// Dir is the repo-relative kustomization directory.
+	Dir string
+	// Severity is "error" or "warning".
+	Severity string
+	Message  string
+}
+
+// kustomizationNames are the file names kustomize accepts, in lookup
+// order.
+var kustomizationNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// HasKustomization reports whether dir contains a kustomization file.
+func HasKustomization(dir string) bool {
+	for _, name := range kustomizationNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk lints every kustomization directory under root (repo-relative
+// directory under repoRoot) and returns the issues sorted by directory.
+func Walk(repoRoot, root string) ([]Issue, error) {
+	var issues []Issue
+	err := filepath.WalkDir(filepath.Join(repoRoot, root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if !HasKustomization(p) {
+			return nil
+		}
+		rel, err := filepath.Rel(repoRoot, p)
+		if err != nil {
+			return err
+		}
+		found, err := Dir(p, rel)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Dir < issues[j].Dir })
+	return issues, nil
+}
+
+// Dir lints a single kustomization directory; rel is its repo-relative
+// path used in issues.
+func Dir(dir, rel string) ([]Issue, error) {
+	kustomization, err := load(dir)
+	if err != nil {
+		return []Issue{{Dir: rel, Severity: "error", Message: err.Error()}}, nil
+	}
+	var issues []Issue
+	add := func(severity, format string, args ...any) {
+		issues = append(issues, Issue{Dir: rel, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+	referenced := map[string]bool{}
+	refs := append([]string{}, stringList(kustomization, "resources")...)
+	refs = append(refs, stringList(kustomization, "bases")...)
+	refs = append(refs, stringList(kustomization, "components")...)
+	refs = append(refs, stringList(kustomization, "patchesStrategicMerge")...)
+	refs = append(refs, patchPaths(kustomization, "patches")...)
+	refs = append(refs, patchPaths(kustomization, "patchesJson6902")...)
+	refs = append(refs, generatorFiles(kustomization, "configMapGenerator")...)
+	refs = append(refs, generatorFiles(kustomization, "secretGenerator")...)
+	seen := map[string]int{}
+	for _, r := range stringList(kustomization, "resources") {
+		seen[r]++
+	}
+	for r, n := range seen {
+		if n > 1 {
+			add("error", "resource %q declared %d times", r, n)
+		}
+	}
+	for _, r := range refs {
+		if strings.Contains(r, "://") {
+			continue
+		}
+		referenced[filepath.Clean(r)] = true
+		if _, err := os.Stat(filepath.Join(dir, r)); err != nil {
+			add("error", "reference %q does not exist", r)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || referenced[name] || isKustomizationName(name) {
+			continue
+		}
+		if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+			add("warning", "file %q is not referenced by the kustomization", name)
+		}
+	}
+	for _, gen := range []string{"generators", "transformers"} {
+		if len(stringList(kustomization, gen)) > 0 {
+			add("warning", "%s run external programs and may render non-deterministically", gen)
+		}
+	}
+	for _, chart := range mapList(kustomization, "helmCharts") {
+		name, _ := chart["name"].(string)
+		if version, _ := chart["version"].(string); version == "" {
+			add("warning", "helm chart %q has no pinned version and may render non-deterministically", name)
+		}
+	}
+	return issues, nil
+}
+
+// load parses the kustomization file in dir.
+func load(dir string) (map[string]any, error) {
+	for _, name := range kustomizationNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var doc map[string]any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("missing kustomization.yaml")
+}
+
+func isKustomizationName(name string) bool {
+	for _, k := range kustomizationNames {
+		if name == k {
+			return true
+		}
+	}
+	return false
+}
+
+func stringList(doc map[string]any, key string) []string {
+	items, _ := doc[key].([]any)
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func mapList(doc map[string]any, key string) []map[string]any {
+	items, _ := doc[key].([]any)
+	var out []map[string]any
+	for _, item := range items {
+		if m, ok := item.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// patchPaths extracts the file paths of patch entries, which may be plain
+// strings or maps with a "path" key; inline patches have no file.
+func patchPaths(doc map[string]any, key string) []string {
+	items, _ := doc[key].([]any)
+	var out []string
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			out = append(out, v)
+		case map[string]any:
+			if p, ok := v["path"].(string); ok && p != "" {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// generatorFiles extracts the local files configMap/secret generators
+// read: files (with optional key= prefixes) and env files.
+func generatorFiles(doc map[string]any, key string) []string {
+	var out []string
+	for _, gen := range mapList(doc, key) {
+		for _, f := range stringList(gen, "files") {
+			if _, file, found := strings.Cut(f, "="); found {
+				f = file
+			}
+			out = append(out, f)
+		}
+		out = append(out, stringList(gen, "envs")...)
+		if env, ok := gen["env"].(string); ok && env != "" {
+			out = append(out, env)
+		}
+	}
+	return out
+}
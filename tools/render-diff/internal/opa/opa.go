@@ -0,0 +1,84 @@
+// Package opa evaluates conftest-style Rego policies against rendered head
+// manifests, so org policies are enforced at render time rather than after
+// deployment.
+package opa
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/open-policy-agent/opa/rego"
+	"gopkg.in/yaml.v3"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// FindingsFor evaluates the Rego policies under policyDir against every
+// resource in the head renders. Following the conftest convention, rules in
+// package main named deny produce critical findings and rules named warn
+// produce warnings; rule values are the finding messages. Requires the
+// engine to have run with KeepRenders.
+func FindingsFor(ctx context.Context, policyDir string, result *engine.DiffResult) ([]engine.Finding, error) {
+	if _, err := os.Stat(policyDir); err != nil {
+		return nil, fmt.Errorf("policy directory %s: %w", policyDir, err)
+	}
+	queries := map[string]string{
+		"data.main.deny": "critical",
+		"data.main.warn": "warning",
+	}
+	var findings []engine.Finding
+	for query, severity := range queries {
+		prepared, err := rego.New(
+			rego.Query(query),
+			rego.Load([]string{policyDir}, nil),
+		).PrepareForEval(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("preparing rego query %s: %w", query, err)
+		}
+		for _, c := range result.Components {
+			if c.HeadRender == "" {
+				continue
+			}
+			for _, doc := range strings.Split(c.HeadRender, "\n---") {
+				var input any
+				if yaml.Unmarshal([]byte(doc), &input) != nil || input == nil {
+					continue
+				}
+				rs, err := prepared.Eval(ctx, rego.EvalInput(input))
+				if err != nil {
+					return nil, fmt.Errorf("evaluating %s for %s: %w", query, c.Path.Path, err)
+				}
+				for _, msg := range messages(rs) {
+					findings = append(findings, engine.Finding{
+						Severity:  severity,
+						Category:  "rego",
+						Component: c.Path.Path,
+						Message:   msg,
+					})
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+// messages flattens an evaluation result into its string rule values.
+func messages(rs rego.ResultSet) []string {
+	var out []string
+	for _, result := range rs {
+		for _, expr := range result.Expressions {
+			values, ok := expr.Value.([]any)
+			if !ok {
+				continue
+			}
+			for _, v := range values {
+				if s, ok := v.(string); ok {
+					out = append(out, s)
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+// Package owners resolves who should review changes to a component from
+// OWNERS files placed in component directories.
+package owners
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the per-directory owners file, in the Kubernetes OWNERS
+// format. Entries containing a slash ("org/team-name") are GitHub teams;
+// plain entries are usernames.
+const FileName = "OWNERS"
+
+// Owners lists the people and teams responsible for a directory.
+type Owners struct {
+	Approvers []string `yaml:"approvers"`
+	Reviewers []string `yaml:"reviewers"`
+}
+
+// ForComponent returns the owners covering component, looking for an OWNERS
+// file in the component directory first and walking up to repoRoot so a
+// top-level file can cover the whole tree. A missing file yields nil.
+func ForComponent(repoRoot, component string) (*Owners, error) {
+	dir := component
+	for {
+		data, err := os.ReadFile(filepath.Join(repoRoot, dir, FileName))
+		if err == nil {
+			var o Owners
+			if err := yaml.Unmarshal(data, &o); err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", filepath.Join(dir, FileName), err)
+			}
+			return &o, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || parent == "." || parent == "/" {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// ReviewersFor aggregates the reviewers (falling back to approvers) of every
+// given component directory into deduplicated user and team lists.
+func ReviewersFor(repoRoot string, components []string) (users, teams []string, err error) {
+	seen := map[string]bool{}
+	for _, component := range components {
+		o, err := ForComponent(repoRoot, component)
+		if err != nil {
+			return nil, nil, err
+		}
+		if o == nil {
+			continue
+		}
+		entries := o.Reviewers
+		if len(entries) == 0 {
+			entries = o.Approvers
+		}
+		for _, entry := range entries {
+			if seen[entry] {
+				continue
+			}
+			seen[entry] = true
+			if _, team, found := strings.Cut(entry, "/"); found {
+				teams = append(teams, team)
+			} else {
+				users = append(users, entry)
+			}
+		}
+	}
+	sort.Strings(users)
+	sort.Strings(teams)
+	return users, teams, nil
+}
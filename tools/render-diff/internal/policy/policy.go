@@ -0,0 +1,121 @@
+// Package policy maps rendered-impact conditions to check conclusions, so
+// repositories can gate merges on what a change does to the effective
+// manifests rather than on raw file diffs.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// Rule fires a conclusion when its condition holds for the diff result.
+type Rule struct {
+	// When names the condition: "build-error", "any-diff",
+	// "production-diff" or "crd-removal".
+	When string `yaml:"when"`
+	// MaxLines scopes production-diff rules: the rule fires only when the
+	// production changed-line count exceeds it. Zero means any diff.
+	MaxLines int `yaml:"maxLines"`
+	// Conclusion is "success", "neutral" or "failure".
+	Conclusion string `yaml:"conclusion"`
+}
+
+// Policy is an ordered rule list with a default conclusion when nothing
+// fires.
+type Policy struct {
+	Rules   []Rule `yaml:"rules"`
+	Default string `yaml:"default"`
+}
+
+// Load reads a policy file and validates its rules.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing policy %s: %w", path, err)
+	}
+	if p.Default == "" {
+		p.Default = "success"
+	}
+	for _, r := range p.Rules {
+		if severity(r.Conclusion) < 0 {
+			return nil, fmt.Errorf("policy %s: unknown conclusion %q", path, r.Conclusion)
+		}
+		switch r.When {
+		case "build-error", "any-diff", "production-diff", "crd-removal":
+		default:
+			return nil, fmt.Errorf("policy %s: unknown condition %q", path, r.When)
+		}
+	}
+	return &p, nil
+}
+
+// Evaluate returns the most severe conclusion among the matching rules,
+// with a reason naming the condition that fired.
+func (p *Policy) Evaluate(result *engine.DiffResult) (conclusion, reason string) {
+	conclusion, reason = p.Default, "no policy rule matched"
+	for _, r := range p.Rules {
+		if !matches(r, result) {
+			continue
+		}
+		if severity(r.Conclusion) > severity(conclusion) {
+			conclusion, reason = r.Conclusion, "condition "+r.When+" matched"
+		}
+	}
+	return conclusion, reason
+}
+
+func matches(r Rule, result *engine.DiffResult) bool {
+	switch r.When {
+	case "build-error":
+		for _, c := range result.Components {
+			if c.Error != "" {
+				return true
+			}
+		}
+	case "any-diff":
+		return result.HasDiffs()
+	case "production-diff":
+		lines := 0
+		for _, c := range result.Components {
+			if c.Path.Environment == "production" {
+				lines += c.Added + c.Removed
+			}
+		}
+		return lines > r.MaxLines
+	case "crd-removal":
+		for _, c := range result.Components {
+			if c.Deleted && strings.Contains(c.Diff, "kind: CustomResourceDefinition") {
+				return true
+			}
+			for _, line := range strings.Split(c.Diff, "\n") {
+				if strings.HasPrefix(line, "-") && strings.Contains(line, "kind: CustomResourceDefinition") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// severity orders conclusions; unknown values map to -1 for validation.
+func severity(conclusion string) int {
+	switch conclusion {
+	case "success":
+		return 0
+	case "neutral":
+		return 1
+	case "failure":
+		return 2
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+func TestEvaluateDefaultWhenNothingMatches(t *testing.T) {
+	p := &Policy{Default: "success", Rules: []Rule{{When: "build-error", Conclusion: "failure"}}}
+	conclusion, reason := p.Evaluate(&engine.DiffResult{})
+	if conclusion != "success" {
+		t.Fatalf("conclusion = %q, want success", conclusion)
+	}
+	if reason != "no policy rule matched" {
+		t.Fatalf("reason = %q", reason)
+	}
+}
+
+func TestEvaluatePicksMostSevereConclusion(t *testing.T) {
+	p := &Policy{
+		Default: "success",
+		Rules: []Rule{
+			{When: "any-diff", Conclusion: "neutral"},
+			{When: "build-error", Conclusion: "failure"},
+		},
+	}
+	result := &engine.DiffResult{Components: []engine.ComponentDiff{
+		{Diff: "+x\n"},
+		{Error: "boom"},
+	}}
+	conclusion, reason := p.Evaluate(result)
+	if conclusion != "failure" {
+		t.Fatalf("conclusion = %q, want failure", conclusion)
+	}
+	if reason != "condition build-error matched" {
+		t.Fatalf("reason = %q", reason)
+	}
+}
+
+func TestEvaluateProductionDiffThreshold(t *testing.T) {
+	p := &Policy{Default: "success", Rules: []Rule{
+		{When: "production-diff", MaxLines: 10, Conclusion: "failure"},
+	}}
+	result := &engine.DiffResult{Components: []engine.ComponentDiff{
+		{Path: appset.ComponentPath{Environment: "production"}, Added: 5, Removed: 3},
+		{Path: appset.ComponentPath{Environment: "staging"}, Added: 100},
+	}}
+	if conclusion, _ := p.Evaluate(result); conclusion != "success" {
+		t.Fatalf("under-threshold production diff should not fire, got %q", conclusion)
+	}
+	result.Components[0].Added = 20
+	if conclusion, _ := p.Evaluate(result); conclusion != "failure" {
+		t.Fatalf("over-threshold production diff should fire, got %q", conclusion)
+	}
+}
+
+func TestEvaluateCRDRemoval(t *testing.T) {
+	p := &Policy{Default: "success", Rules: []Rule{{When: "crd-removal", Conclusion: "failure"}}}
+	result := &engine.DiffResult{Components: []engine.ComponentDiff{
+		{Diff: "-kind: CustomResourceDefinition\n"},
+	}}
+	if conclusion, _ := p.Evaluate(result); conclusion != "failure" {
+		t.Fatalf("removed CRD line should fire, got %q", conclusion)
+	}
+}
+
+func TestLoadValidatesRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "policy.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	p, err := Load(write("rules:\n  - when: any-diff\n    conclusion: neutral\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p.Default != "success" {
+		t.Fatalf("missing default should fall back to success, got %q", p.Default)
+	}
+	if _, err := Load(write("rules:\n  - when: full-moon\n    conclusion: failure\n")); err == nil {
+		t.Fatal("expected error for unknown condition")
+	}
+	if _, err := Load(write("rules:\n  - when: any-diff\n    conclusion: maybe\n")); err == nil {
+		t.Fatal("expected error for unknown conclusion")
+	}
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// EnvResult is the per-environment verdict written as result-<env>.json, so
+// downstream pipeline stages (an approval gate for production, say) can
+// consume their environment's outcome without parsing the combined result.
+type EnvResult struct {
+	Environment string                 `json:"environment"`
+	BaseSHA     string                 `json:"baseSHA"`
+	HeadSHA     string                 `json:"headSHA"`
+	HasDiffs    bool                   `json:"hasDiffs"`
+	BuildErrors bool                   `json:"buildErrors"`
+	Components  []engine.ComponentDiff `json:"components"`
+}
+
+// WriteEnvResults writes one result-<env>.json per environment present in
+// the result into dir. Components without an environment are grouped under
+// "none".
+func WriteEnvResults(result *engine.DiffResult, dir string) error {
+	byEnv := map[string]*EnvResult{}
+	for _, c := range result.Components {
+		env := c.Path.Environment
+		if env == "" {
+			env = "none"
+		}
+		er := byEnv[env]
+		if er == nil {
+			er = &EnvResult{Environment: env, BaseSHA: result.BaseSHA, HeadSHA: result.HeadSHA}
+			byEnv[env] = er
+		}
+		er.Components = append(er.Components, c)
+		if c.Diff != "" {
+			er.HasDiffs = true
+		}
+		if c.Error != "" {
+			er.BuildErrors = true
+		}
+	}
+	envs := make([]string, 0, len(byEnv))
+	for env := range byEnv {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	for _, env := range envs {
+		data, err := json.MarshalIndent(byEnv[env], "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "result-"+env+".json"), append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/history"
+)
+
+// NoisyIssueMarker tags the tracking issue maintained by the noisy-component
+// digest.
+const NoisyIssueMarker = "<!-- render-diff/noisy-components -->"
+
+// NoisyComponent is a component that produced rendered diffs in a large
+// fraction of the recorded pull requests.
+type NoisyComponent struct {
+	Component string
+	// DiffPRs is the number of distinct PRs in which the component diffed.
+	DiffPRs int
+	// TotalPRs is the number of distinct PRs in the history window.
+	TotalPRs int
+	// Rate is DiffPRs / TotalPRs.
+	Rate float64
+}
+
+// Noisy scans history entries and returns components that diffed in at least
+// minRate of the distinct PRs recorded, provided the window covers at least
+// minPRs pull requests. Entries without a PR number (local runs) are ignored.
+func Noisy(entries []history.Entry, minRate float64, minPRs int) []NoisyComponent {
+	prs := map[int]bool{}
+	diffPRs := map[string]map[int]bool{}
+	for _, e := range entries {
+		if e.PR == 0 {
+			continue
+		}
+		prs[e.PR] = true
+		for _, c := range e.Components {
+			if !c.HasDiff {
+				continue
+			}
+			if diffPRs[c.Component] == nil {
+				diffPRs[c.Component] = map[int]bool{}
+			}
+			diffPRs[c.Component][e.PR] = true
+		}
+	}
+	if len(prs) < minPRs {
+		return nil
+	}
+	var out []NoisyComponent
+	for component, inPRs := range diffPRs {
+		rate := float64(len(inPRs)) / float64(len(prs))
+		if rate >= minRate {
+			out = append(out, NoisyComponent{
+				Component: component,
+				DiffPRs:   len(inPRs),
+				TotalPRs:  len(prs),
+				Rate:      rate,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Rate != out[j].Rate {
+			return out[i].Rate > out[j].Rate
+		}
+		return out[i].Component < out[j].Component
+	})
+	return out
+}
+
+// NoisyDigest renders the markdown body of the noisy-component tracking
+// issue, including suggested ignore rules reviewers can adopt.
+func NoisyDigest(noisy []NoisyComponent) string {
+	var b strings.Builder
+	b.WriteString("## Recurring noisy components\n\n")
+	if len(noisy) == 0 {
+		b.WriteString("No component exceeded the noise threshold in the recorded window.\n")
+		return b.String()
+	}
+	b.WriteString("The components below produced rendered diffs in a high share of recent PRs. ")
+	b.WriteString("Frequent diffs usually mean a non-deterministic generator or an overlay that every PR touches; ")
+	b.WriteString("consider fixing the source of churn or adding an ignore rule.\n\n")
+	b.WriteString("| Component | Diffed in | Rate |\n|---|---|---|\n")
+	for _, n := range noisy {
+		fmt.Fprintf(&b, "| `%s` | %d of %d PRs | %.0f%% |\n", n.Component, n.DiffPRs, n.TotalPRs, n.Rate*100)
+	}
+	b.WriteString("\nSuggested ignore rules:\n\n```yaml\nignore:\n")
+	for _, n := range noisy {
+		fmt.Fprintf(&b, "  - component: %s\n", n.Component)
+	}
+	b.WriteString("```\n")
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/history"
+)
+
+func noisyEntries() []history.Entry {
+	return []history.Entry{
+		{PR: 1, Components: []history.ComponentRecord{
+			{Component: "components/spi", HasDiff: true},
+			{Component: "components/monitoring", HasDiff: false},
+		}},
+		{PR: 2, Components: []history.ComponentRecord{
+			{Component: "components/spi", HasDiff: true},
+			{Component: "components/monitoring", HasDiff: true},
+		}},
+		// Local run without a PR number: must not count toward the window.
+		{PR: 0, Components: []history.ComponentRecord{
+			{Component: "components/monitoring", HasDiff: true},
+		}},
+		{PR: 3, Components: []history.ComponentRecord{
+			{Component: "components/spi", HasDiff: true},
+		}},
+		// Second record for PR 2: distinct PRs, not entries, are counted.
+		{PR: 2, Components: []history.ComponentRecord{
+			{Component: "components/spi", HasDiff: true},
+		}},
+	}
+}
+
+func TestNoisyRates(t *testing.T) {
+	got := Noisy(noisyEntries(), 0.3, 3)
+	if len(got) != 2 {
+		t.Fatalf("got %d components, want 2: %+v", len(got), got)
+	}
+	// Sorted by rate descending, then name.
+	if got[0].Component != "components/spi" || got[0].DiffPRs != 3 || got[0].TotalPRs != 3 || got[0].Rate != 1.0 {
+		t.Fatalf("spi = %+v", got[0])
+	}
+	if got[1].Component != "components/monitoring" || got[1].DiffPRs != 1 {
+		t.Fatalf("monitoring = %+v", got[1])
+	}
+}
+
+func TestNoisyMinRate(t *testing.T) {
+	got := Noisy(noisyEntries(), 0.5, 3)
+	if len(got) != 1 || got[0].Component != "components/spi" {
+		t.Fatalf("rate threshold should drop monitoring, got %+v", got)
+	}
+}
+
+func TestNoisyRequiresEnoughPRs(t *testing.T) {
+	if got := Noisy(noisyEntries(), 0.5, 10); got != nil {
+		t.Fatalf("window below minPRs should return nil, got %+v", got)
+	}
+}
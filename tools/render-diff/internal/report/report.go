@@ -0,0 +1,277 @@
+// Package report turns DiffResults into the tool's user-facing outputs:
+// terminal text, PR comment markdown and machine-readable JSON.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// CommentMarker tags the PR comment maintained by render-diff.
+const CommentMarker = "<!-- render-diff -->"
+
+// BodySectionBegin and BodySectionEnd delimit the render-diff section when
+// the report is maintained inside the PR description instead of a comment.
+const (
+	BodySectionBegin = "<!-- render-diff:begin -->"
+	BodySectionEnd   = "<!-- render-diff:end -->"
+)
+
+// Text renders a result for terminal consumption.
+func Text(result *engine.DiffResult) string {
+	var b strings.Builder
+	for _, c := range result.Components {
+		if c.Error != "" {
+			fmt.Fprintf(&b, "ERROR %s: %s\n", c.Path.Path, c.Error)
+			continue
+		}
+		if c.Diff == "" {
+			continue
+		}
+		label := ""
+		if c.Deleted {
+			label = " DELETED"
+		}
+		fmt.Fprintf(&b, "=== %s%s (+%d -%d)\n%s\n", c.Path.Path, label, c.Added, c.Removed, c.Diff)
+	}
+	for _, f := range result.Findings {
+		label := "WARNING"
+		if f.Category == "security" {
+			label = "SECURITY"
+		}
+		fmt.Fprintf(&b, "%s [%s] %s\n", label, f.Category, f.Message)
+	}
+	for _, r := range result.Removals {
+		fmt.Fprintf(&b, "REMOVED %s", r.Path.Path)
+		if len(r.StillPresentIn) > 0 {
+			fmt.Fprintf(&b, " (still present in: %s)", strings.Join(r.StillPresentIn, ", "))
+		}
+		b.WriteString("\n")
+	}
+	if b.Len() == 0 {
+		b.WriteString("No render differences detected.\n")
+	}
+	if slowest := slowComponents(result.Components); len(slowest) > 0 {
+		b.WriteString("\nSlowest components:\n")
+		for _, c := range slowest {
+			fmt.Fprintf(&b, "  %6dms build %6dms diff  %s\n", c.BuildMillis, c.DiffMillis, c.Path.Path)
+		}
+	}
+	return b.String()
+}
+
+// slowComponents returns the components worth calling out in the timing
+// breakdown: the five slowest, and only those that took at least a second —
+// a fast run stays free of timing noise.
+func slowComponents(components []engine.ComponentDiff) []engine.ComponentDiff {
+	var slow []engine.ComponentDiff
+	for _, c := range components {
+		if c.BuildMillis+c.DiffMillis >= 1000 {
+			slow = append(slow, c)
+		}
+	}
+	sort.Slice(slow, func(i, j int) bool {
+		return slow[i].BuildMillis+slow[i].DiffMillis > slow[j].BuildMillis+slow[j].DiffMillis
+	})
+	if len(slow) > 5 {
+		slow = slow[:5]
+	}
+	return slow
+}
+
+// Summary condenses a result to a single line for quiet runs.
+func Summary(result *engine.DiffResult) string {
+	changed, added, removed, errs := 0, 0, 0, 0
+	for _, c := range result.Components {
+		if c.Error != "" {
+			errs++
+			continue
+		}
+		if c.Diff == "" {
+			continue
+		}
+		changed++
+		added += c.Added
+		removed += c.Removed
+	}
+	if changed == 0 && errs == 0 && len(result.Findings) == 0 && len(result.Removals) == 0 {
+		return "No render differences detected.\n"
+	}
+	line := fmt.Sprintf("%d component(s) changed (+%d -%d)", changed, added, removed)
+	if errs > 0 {
+		line += fmt.Sprintf(", %d build error(s)", errs)
+	}
+	if len(result.Findings) > 0 {
+		line += fmt.Sprintf(", %d finding(s)", len(result.Findings))
+	}
+	if len(result.Removals) > 0 {
+		line += fmt.Sprintf(", %d removed path(s)", len(result.Removals))
+	}
+	return line + "\n"
+}
+
+// Markdown renders a result as the body of the PR comment.
+func Markdown(result *engine.DiffResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Render diff\n\nComparing `%s` (`%.12s`) with `%.12s`.\n\n", result.BaseRef, result.BaseSHA, result.HeadSHA)
+	changed := 0
+	for _, c := range result.Components {
+		if c.Diff != "" || c.Error != "" {
+			changed++
+		}
+	}
+	if changed == 0 && len(result.Removals) == 0 && len(result.Findings) == 0 {
+		b.WriteString("No render differences detected.\n")
+		return b.String()
+	}
+	rest := result.Findings
+	for _, section := range findingSections {
+		var matched []engine.Finding
+		matched, rest = splitCategory(rest, section.category)
+		if len(matched) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s\n\n", section.heading)
+		for _, f := range matched {
+			fmt.Fprintf(&b, "- **%s** `%s`: %s\n", f.Severity, f.Component, f.Message)
+		}
+		b.WriteString("\n")
+	}
+	if len(rest) > 0 {
+		b.WriteString("### Warnings\n\n")
+		for _, f := range rest {
+			fmt.Fprintf(&b, "- **%s** (%s): %s\n", f.Severity, f.Category, f.Message)
+		}
+		b.WriteString("\n")
+	}
+	if len(result.Removals) > 0 {
+		b.WriteString("### Removed component paths\n\n")
+		for _, r := range result.Removals {
+			fmt.Fprintf(&b, "- `%s`", r.Path.Path)
+			if len(r.StillPresentIn) > 0 {
+				fmt.Fprintf(&b, " — still present in: %s", strings.Join(r.StillPresentIn, ", "))
+			} else {
+				b.WriteString(" — removed from every environment")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if changed > 0 {
+		b.WriteString("| Component | Environment | Cluster | Changes |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, c := range result.Components {
+			if c.Diff == "" && c.Error == "" {
+				continue
+			}
+			status := fmt.Sprintf("+%d / -%d", c.Added, c.Removed)
+			if c.Deleted {
+				status = fmt.Sprintf("**deleted** (-%d)", c.Removed)
+			}
+			if c.Error != "" {
+				status = "build error"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", c.Path.Component, c.Path.Environment, c.Path.ClusterDir, status)
+		}
+		b.WriteString("\n")
+	}
+	for _, c := range result.Components {
+		if c.Diff == "" && c.Error == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "<details><summary><code>%s</code></summary>\n\n", c.Path.Path)
+		if c.Error != "" {
+			fmt.Fprintf(&b, "```\n%s\n```\n", c.Error)
+		} else {
+			fmt.Fprintf(&b, "```diff\n%s```\n", c.Diff)
+		}
+		b.WriteString("\n</details>\n\n")
+	}
+	return b.String()
+}
+
+// findingSections lists the finding categories that get a dedicated
+// section in the comment instead of landing under the generic warnings.
+var findingSections = []struct{ category, heading string }{
+	{"security", "Security-relevant changes"},
+	{"sync-order", "Apply-order changes"},
+}
+
+// splitCategory separates findings of one category from the rest.
+func splitCategory(findings []engine.Finding, category string) (matched, other []engine.Finding) {
+	for _, f := range findings {
+		if f.Category == category {
+			matched = append(matched, f)
+		} else {
+			other = append(other, f)
+		}
+	}
+	return matched, other
+}
+
+// riskOf grades an environment for the approval checklist.
+func riskOf(environment string) string {
+	switch environment {
+	case "production":
+		return "high"
+	case "staging":
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// Checklist renders one checkbox per affected environment/cluster with its
+// risk level, for reviewers to tick off before merging.
+func Checklist(result *engine.DiffResult) string {
+	type key struct{ env, cluster string }
+	seen := map[key]bool{}
+	var keys []key
+	for _, c := range result.Components {
+		if c.Diff == "" && c.Error == "" {
+			continue
+		}
+		k := key{c.Path.Environment, c.Path.ClusterDir}
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].env != keys[j].env {
+			return keys[i].env < keys[j].env
+		}
+		return keys[i].cluster < keys[j].cluster
+	})
+	var b strings.Builder
+	b.WriteString("### Approval checklist\n\n")
+	for _, k := range keys {
+		label := k.env
+		if label == "" {
+			label = "(no environment)"
+		}
+		if k.cluster != "" {
+			label += " / " + k.cluster
+		}
+		fmt.Fprintf(&b, "- [ ] %s (risk: %s)\n", label, riskOf(k.env))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// WriteJSON writes the result as indented JSON to path.
+func WriteJSON(result *engine.DiffResult, path string) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/ledger"
+)
+
+// newLedgerCmd builds the "ledger" subcommand, intended for a post-merge
+// job: it condenses a stored result into one ledger line and optionally
+// commits the updated ledger back to the repository.
+func newLedgerCmd() *cobra.Command {
+	var (
+		from       string
+		file       string
+		commitBack bool
+	)
+	cmd := &cobra.Command{
+		Use:   "ledger",
+		Short: "Append a merged change's render delta to the repo ledger",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := loadResult(from)
+			if err != nil {
+				return err
+			}
+			gitClient := git.New(".")
+			repoRoot, err := gitClient.TopLevel(cmd.Context())
+			if err != nil {
+				return err
+			}
+			record := ledger.FromResult(result)
+			if err := ledger.Append(filepath.Join(repoRoot, file), record); err != nil {
+				return err
+			}
+			if !commitBack {
+				return nil
+			}
+			message := fmt.Sprintf("Record render delta for %.12s in ledger", record.SHA)
+			return gitClient.Commit(cmd.Context(), message, file)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "result.json file or artifact directory containing one")
+	cmd.Flags().StringVar(&file, "file", ".render-diff/ledger.jsonl", "repo-relative ledger file to append to")
+	cmd.Flags().BoolVar(&commitBack, "commit", false, "commit the updated ledger")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/lint"
+)
+
+// newLintCmd builds the "lint" subcommand, which checks kustomization
+// hygiene across the repo without rendering or diffing anything, so CI can
+// run it on every PR regardless of what changed.
+func newLintCmd() *cobra.Command {
+	var (
+		roots        []string
+		overlaysDirs []string
+		warnAsError  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check components for kustomize hygiene issues",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			repoRoot, err := gitClient.TopLevel(ctx)
+			if err != nil {
+				return err
+			}
+			var issues []lint.Issue
+			for _, root := range roots {
+				found, err := lint.Walk(repoRoot, root)
+				if err != nil {
+					return err
+				}
+				issues = append(issues, found...)
+			}
+			// Every path an ApplicationSet renders must have a
+			// kustomization; a missing one fails the Argo CD sync, not
+			// just the preview.
+			paths, err := discoverAll(repoRoot, overlaysDirs)
+			if err != nil {
+				return err
+			}
+			for _, p := range paths {
+				if !lint.HasKustomization(filepath.Join(repoRoot, p.Path)) {
+					issues = append(issues, lint.Issue{Dir: p.Path, Severity: "error", Message: "rendered by " + p.AppSet + " but has no kustomization.yaml"})
+				}
+			}
+			errors := 0
+			for _, issue := range issues {
+				fmt.Fprintf(os.Stderr, "%s %s: %s\n", issue.Severity, issue.Dir, issue.Message)
+				if issue.Severity == "error" || warnAsError {
+					errors++
+				}
+			}
+			if errors > 0 {
+				return fmt.Errorf("%d lint issue(s)", errors)
+			}
+			if len(issues) == 0 {
+				fmt.Println("no lint issues found")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&roots, "root", []string{"components", "argo-cd-apps"}, "repo-relative directories to lint (repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	cmd.Flags().BoolVar(&warnAsError, "warnings-as-errors", false, "treat warnings as errors for the exit code")
+	return cmd
+}
@@ -0,0 +1,1109 @@
+// render-diff renders the kustomize output of components affected by a
+// change and reports the differences, so reviewers see the effective
+// manifest delta of a PR instead of raw file diffs.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/agekey"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/analysis"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/cache"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/capacity"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/cluster"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/deprecation"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/diffutil"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/envsubst"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/history"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/kyverno"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/opa"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/owners"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/policy"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/report"
+)
+
+// options holds the root command's flag values.
+type options struct {
+	baseRef      string
+	headRef      string
+	overlaysDirs []string
+	output       string
+	outputFile   string
+	kustomize    string
+	historyDir   string
+	githubRepo   string
+	prNumber     int
+	comment      bool
+	all          bool
+	ageKey       string
+	include      []string
+	exclude      []string
+	cluster      string
+	checklist    bool
+
+	capacityFile     string
+	capacityEndpoint string
+	reuseWorktrees   bool
+	dirty            bool
+	includeUntracked bool
+	baseRepo         string
+	perCommit        bool
+	riskLabels       bool
+	largeDiffLines   int
+	requestReviews   bool
+	prDescription    bool
+	checkPolicy      string
+	enableHelm       bool
+	helmBin          string
+	enablePlugins    bool
+	kustomizeVersion string
+	loadRestrictor   string
+	buildFlags       []string
+	failOnBuildError bool
+	cacheRemoteBases bool
+	buildTimeout     time.Duration
+	memoryThrottle   bool
+	noNetwork        bool
+	regoDir          string
+	failOnViolations bool
+	kyvernoDir       string
+	kyvernoBin       string
+	failOnNSDeletion bool
+	failOnCRDRemoval bool
+	crdOverrideLabel string
+	maxDiffLines     int
+	maxComponents    int
+	requireAck       bool
+	ackLabel         string
+	recurseApps      bool
+	againstCluster   string
+	baselineDir      string
+	renderCache      string
+	renderCacheURL   string
+	timingsFile      string
+	concurrency      int
+	quiet            bool
+	verbosity        int
+}
+
+// thresholdExceededError reports a diff that blew past --max-diff-lines or
+// --max-components. It carries its own exit code so CI can distinguish
+// "too large, needs acknowledgment" from ordinary failures.
+type thresholdExceededError struct{ msg string }
+
+func (e *thresholdExceededError) Error() string { return e.msg }
+
+// exitCodeThresholdExceeded is returned when a size threshold is exceeded.
+const exitCodeThresholdExceeded = 3
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		var threshold *thresholdExceededError
+		if errors.As(err, &threshold) {
+			os.Exit(exitCodeThresholdExceeded)
+		}
+		var coded *exitCodeError
+		if errors.As(err, &coded) {
+			os.Exit(coded.code)
+		}
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	opts := &options{}
+	cmd := &cobra.Command{
+		Use:   "render-diff",
+		Short: "Render and diff the kustomize output of affected components",
+		Long: "render-diff detects which Argo CD managed components a change affects,\n" +
+			"renders them with kustomize on both the base and head side, and reports\n" +
+			"the differences in the rendered manifests.",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.baseRef, "base-ref", "", "base ref to compare against (default: the remote default branch from origin/HEAD)")
+	cmd.Flags().StringVar(&opts.headRef, "head-ref", "HEAD", "head ref to compare (any ref, enabling release-to-release audits)")
+	cmd.Flags().StringSliceVar(&opts.overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	cmd.Flags().StringVar(&opts.output, "output", "text", "output format: text, markdown or json")
+	cmd.Flags().StringVar(&opts.outputFile, "output-file", "", "write output to a file instead of stdout")
+	cmd.Flags().StringVar(&opts.kustomize, "kustomize-bin", "", "kustomize binary to use instead of the built-in renderer")
+	cmd.Flags().StringVar(&opts.historyDir, "history-dir", "", "append a run record to the history store in this directory")
+	cmd.Flags().StringVar(&opts.githubRepo, "github-repo", "", "GitHub repository (owner/repo) for comment mode")
+	cmd.Flags().IntVar(&opts.prNumber, "pr-number", 0, "pull request number for comment mode")
+	cmd.Flags().BoolVar(&opts.comment, "github-comment", false, "post or update the render-diff comment on the PR")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "render and diff every component in every environment, ignoring changed-file detection")
+	cmd.Flags().StringVar(&opts.ageKey, "age-key", "", "age identity source for encrypted overlays (env:VAR, file:PATH or exec:COMMAND)")
+	cmd.Flags().StringSliceVar(&opts.include, "include", nil, "only keep detected paths matching these globs (repeatable)")
+	cmd.Flags().StringSliceVar(&opts.exclude, "exclude", nil, "drop detected paths matching these globs (repeatable)")
+	cmd.Flags().StringVar(&opts.cluster, "cluster", "", "only render paths for this cluster directory (e.g. stone-prd-m01)")
+	cmd.Flags().BoolVar(&opts.checklist, "approval-checklist", false, "append a per-environment approval checklist to the PR comment")
+	cmd.Flags().StringVar(&opts.capacityFile, "capacity-file", "", "static per-cluster capacity YAML for scheduling feasibility warnings")
+	cmd.Flags().StringVar(&opts.capacityEndpoint, "capacity-endpoint", "", "capacity API endpoint for scheduling feasibility warnings")
+	cmd.Flags().BoolVar(&opts.reuseWorktrees, "reuse-worktrees", false, "cache base worktrees by SHA under the user cache dir and reuse them")
+	cmd.Flags().BoolVar(&opts.dirty, "dirty", false, "render the working tree (including uncommitted edits) as the head side")
+	cmd.Flags().BoolVar(&opts.includeUntracked, "include-untracked", false, "consider untracked files in change detection")
+	cmd.Flags().StringVar(&opts.baseRepo, "base-repo", "", "fetch the base ref from this repository URL instead of origin (fork-vs-upstream audits)")
+	cmd.Flags().BoolVar(&opts.perCommit, "per-commit", false, "diff each commit between base and head separately and report which commit introduced each change")
+	cmd.Flags().BoolVar(&opts.riskLabels, "risk-labels", false, "apply render-diff/* risk labels to the PR and remove them when cleared")
+	cmd.Flags().IntVar(&opts.largeDiffLines, "large-diff-lines", 500, "changed-line count above which the render-diff/large label applies")
+	cmd.Flags().BoolVar(&opts.requestReviews, "request-reviews", false, "request reviews from the owners of changed components (per OWNERS files)")
+	cmd.Flags().BoolVar(&opts.prDescription, "pr-description", false, "maintain a Render Diff section in the PR description instead of posting a comment")
+	cmd.Flags().StringVar(&opts.checkPolicy, "check-policy", "", "policy file mapping rendered-impact conditions to a commit status conclusion")
+	cmd.Flags().BoolVar(&opts.enableHelm, "enable-helm", false, "inflate helmCharts entries during builds instead of failing on them")
+	cmd.Flags().StringVar(&opts.helmBin, "helm-bin", "", "helm binary used for chart inflation (default: helm on PATH)")
+	cmd.Flags().BoolVar(&opts.enablePlugins, "enable-plugins", false, "allow KRM functions and exec plugins listed under plugins.allow in "+config.FileName)
+	cmd.Flags().StringVar(&opts.kustomizeVersion, "kustomize-version", "", "download, verify and use this exact kustomize release (e.g. 5.3.0)")
+	cmd.Flags().StringVar(&opts.loadRestrictor, "load-restrictor", "", "kustomize load restriction, e.g. LoadRestrictionsNone for cross-directory references")
+	cmd.Flags().StringSliceVar(&opts.buildFlags, "build-flag", nil, "extra flag passed verbatim to kustomize build (repeatable; binary builders only)")
+	cmd.Flags().BoolVar(&opts.failOnBuildError, "fail-on-build-error", false, "exit non-zero when any component fails to build")
+	cmd.Flags().BoolVar(&opts.cacheRemoteBases, "cache-remote-bases", false, "mirror remote kustomize bases locally and reuse them across builds")
+	cmd.Flags().DurationVar(&opts.buildTimeout, "build-timeout", 2*time.Minute, "per-component build timeout; timed-out components report as build errors (0 to disable)")
+	cmd.Flags().BoolVar(&opts.memoryThrottle, "memory-throttle", true, "reduce build concurrency while the host is low on memory")
+	cmd.Flags().BoolVar(&opts.noNetwork, "no-network", false, "run builds without network access so remote fetches fail loudly")
+	cmd.Flags().StringVar(&opts.regoDir, "rego-policy-dir", "", "evaluate conftest-style Rego policies from this directory against head renders")
+	cmd.Flags().BoolVar(&opts.failOnViolations, "fail-on-violations", false, "exit non-zero when policy evaluation produces critical findings")
+	cmd.Flags().StringVar(&opts.kyvernoDir, "kyverno-policy-dir", "", "apply Kyverno ClusterPolicy files from this directory to head renders")
+	cmd.Flags().StringVar(&opts.kyvernoBin, "kyverno-bin", "", "kyverno binary for policy checks (default: kyverno on PATH)")
+	cmd.Flags().BoolVar(&opts.failOnNSDeletion, "fail-on-namespace-deletion", false, "exit non-zero when the diff deletes a Namespace resource")
+	cmd.Flags().BoolVar(&opts.failOnCRDRemoval, "fail-on-crd-removal", false, "exit non-zero when the diff removes a CustomResourceDefinition")
+	cmd.Flags().StringVar(&opts.crdOverrideLabel, "crd-override-label", "render-diff/allow-crd-removal", "PR label that overrides --fail-on-crd-removal")
+	cmd.Flags().IntVar(&opts.maxDiffLines, "max-diff-lines", 0, fmt.Sprintf("exit with code %d when the total diff exceeds this many lines (0 to disable)", exitCodeThresholdExceeded))
+	cmd.Flags().IntVar(&opts.maxComponents, "max-components", 0, fmt.Sprintf("exit with code %d when more than this many components change (0 to disable)", exitCodeThresholdExceeded))
+	cmd.Flags().BoolVar(&opts.requireAck, "require-ack", false, "report a render-diff/ack commit status that stays pending until findings are acknowledged")
+	cmd.Flags().StringVar(&opts.ackLabel, "ack-label", "render-diff/approved", "PR label that acknowledges findings for --require-ack")
+	cmd.Flags().BoolVar(&opts.recurseApps, "recurse-apps", false, "also render component paths referenced by Application resources in the rendered output (app-of-apps)")
+	cmd.Flags().StringVar(&opts.againstCluster, "against-cluster", "", "diff head renders against live cluster state via this kubeconfig instead of the base ref")
+	cmd.Flags().Lookup("against-cluster").NoOptDefVal = "ambient"
+	cmd.Flags().StringVar(&opts.baselineDir, "baseline-dir", "", "render-all snapshot to diff against instead of building the base ref")
+	cmd.Flags().StringVar(&opts.renderCache, "render-cache", "", "persist renders across runs under this directory (bare flag: the user cache dir, for CI cache actions to save and restore)")
+	cmd.Flags().Lookup("render-cache").NoOptDefVal = "auto"
+	cmd.Flags().StringVar(&opts.renderCacheURL, "render-cache-url", "", "shared render cache endpoint accepting GET and PUT (S3-compatible or plain HTTP); token read from RENDER_DIFF_CACHE_TOKEN")
+	cmd.Flags().StringVar(&opts.timingsFile, "timings", "", "write per-component build and diff durations as JSON to this file")
+	cmd.Flags().IntVar(&opts.concurrency, "concurrency", 0, "parallel build workers (default: adaptive from CPU count, load and memory)")
+	cmd.AddCommand(newReportCmd())
+	cmd.AddCommand(newPublishCmd())
+	cmd.AddCommand(newCheckApprovalsCmd())
+	cmd.AddCommand(newLedgerCmd())
+	cmd.AddCommand(newInitCmd())
+	cmd.AddCommand(newRenderAllCmd())
+	cmd.AddCommand(newLintCmd())
+	cmd.AddCommand(newGraphCmd())
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newDriftCmd())
+	cmd.AddCommand(newPromoteCmd())
+	cmd.AddCommand(newImpactCmd())
+	cmd.AddCommand(newAnnotateCmd())
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newVerifyCmd())
+	cmd.AddCommand(newStatsCmd())
+	cmd.AddCommand(newDoctorCmd())
+	cmd.PersistentFlags().BoolVarP(&opts.quiet, "quiet", "q", false, "only log errors and print a one-line summary")
+	cmd.PersistentFlags().CountVarP(&opts.verbosity, "verbose", "v", "increase log detail (-v debug, -vv debug with source locations)")
+	addProfiling(cmd)
+	// Environment overrides beat config-file defaults, which beat built-in
+	// defaults; both apply before profiling so even those flags can come
+	// from the environment or the repo.
+	pre := cmd.PersistentPreRunE
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		if err := applyEnvOverrides(c); err != nil {
+			return err
+		}
+		if err := applyConfigDefaults(c); err != nil {
+			return err
+		}
+		if err := configureLogging(opts.quiet, opts.verbosity); err != nil {
+			return err
+		}
+		return pre(c, args)
+	}
+	return cmd
+}
+
+func run(ctx context.Context, opts *options) error {
+	gitClient := git.New(".")
+	repoRoot, err := gitClient.TopLevel(ctx)
+	if err != nil {
+		return err
+	}
+	headSHA, err := gitClient.ResolveRef(ctx, opts.headRef)
+	if err != nil {
+		return err
+	}
+	baseRef := opts.baseRef
+	if baseRef == "" {
+		if ref, source := baseRefFromCI(); ref != "" {
+			baseRef = ref
+			slog.Info("base ref taken from CI metadata", "ref", baseRef, "source", source)
+		} else {
+			baseRef = git.DefaultBaseRef(ctx, gitClient)
+			slog.Info("auto-detected base ref", "ref", baseRef)
+		}
+	}
+	displayRef := baseRef
+	if opts.baseRepo != "" {
+		// Pull the foreign base into the local object store so
+		// merge-base, changed-file and worktree plumbing work
+		// unchanged for cross-repository comparisons.
+		const crossRepoRef = "refs/render-diff/base"
+		ref := strings.TrimPrefix(baseRef, "origin/")
+		if err := gitClient.Fetch(ctx, opts.baseRepo, []string{ref + ":" + crossRepoRef}, 0); err != nil {
+			return fmt.Errorf("fetching %s from %s: %w", ref, opts.baseRepo, err)
+		}
+		baseRef = crossRepoRef
+	}
+	baseSHA, err := git.MergeBaseWithFetch(ctx, gitClient, baseRef, headSHA)
+	if err != nil {
+		return err
+	}
+	if opts.dirty && opts.headRef != "HEAD" {
+		return fmt.Errorf("--dirty cannot be combined with --head-ref")
+	}
+	if opts.perCommit {
+		if opts.dirty {
+			return fmt.Errorf("--per-commit cannot be combined with --dirty")
+		}
+		return runPerCommit(ctx, opts, gitClient, baseSHA, headSHA)
+	}
+	diffHead := headSHA
+	if opts.dirty {
+		// Compare the base directly against the working tree so
+		// uncommitted (even unstaged) edits show up in detection; the
+		// head side then renders from the working tree as usual.
+		diffHead = ""
+	}
+	changed, err := gitClient.ChangedFiles(ctx, baseSHA, diffHead)
+	if err != nil {
+		return err
+	}
+	if opts.includeUntracked {
+		untracked, err := gitClient.UntrackedFiles(ctx)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, untracked...)
+	}
+	// With an explicit head ref the working tree may be somewhere else
+	// entirely, so the head side gets its own worktree too.
+	headRoot := repoRoot
+	if opts.headRef != "HEAD" {
+		dir, err := os.MkdirTemp("", "render-diff-head-")
+		if err != nil {
+			return err
+		}
+		cleanup, err := gitClient.CreateWorktree(ctx, dir, headSHA)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := cleanup(); err != nil {
+				slog.Warn("head worktree cleanup failed", "error", err)
+			}
+		}()
+		headRoot = dir
+	}
+	paths, err := discoverAll(headRoot, opts.overlaysDirs)
+	if err != nil {
+		return err
+	}
+	detector := detect.New(paths)
+	if err := detector.ResolveSymlinks(headRoot); err != nil {
+		slog.Warn("symlink resolution failed", "error", err)
+	}
+	if err := detector.ScanKustomizeRefs(headRoot); err != nil {
+		slog.Warn("kustomization scan failed", "error", err)
+	}
+	cfg, err := config.Load(headRoot)
+	if err != nil {
+		return err
+	}
+	detector.AddMappings(cfg.Mappings)
+	var affected []appset.ComponentPath
+	if opts.all {
+		// Full-repo audits (tool upgrades, scheduled runs) render
+		// everything regardless of what changed.
+		affected = detector.Paths()
+	} else {
+		affected = detector.Affected(changed)
+	}
+	affected = detect.Filter(affected, opts.include, opts.exclude)
+	if opts.cluster != "" {
+		var clusterOnly []appset.ComponentPath
+		for _, p := range affected {
+			if p.ClusterDir == opts.cluster {
+				clusterOnly = append(clusterOnly, p)
+			}
+		}
+		affected = clusterOnly
+	}
+	slog.Info("detection complete", "changedFiles", len(changed), "affectedPaths", len(affected), "all", opts.all)
+
+	result := &engine.DiffResult{BaseRef: displayRef, BaseSHA: baseSHA, HeadSHA: headSHA}
+	if len(affected) > 0 {
+		var worktree string
+		var cleanup func() error
+		if opts.baselineDir != "" {
+			// A stored snapshot stands in for the base side; no base
+			// worktree or base builds are needed at all.
+			worktree = headRoot
+			cleanup = func() error { return nil }
+		} else if opts.reuseWorktrees {
+			// Cached worktrees are full checkouts keyed by SHA and
+			// survive the run, so repeated local invocations while
+			// iterating on a PR skip the checkout entirely.
+			worktree, err = cache.WorktreeFor(ctx, baseSHA, func(dir string) error {
+				_, err := gitClient.CreateWorktree(ctx, dir, baseSHA)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			cleanup = func() error { return nil }
+		} else {
+			worktree, err = os.MkdirTemp("", "render-diff-base-")
+			if err != nil {
+				return err
+			}
+			cleanup, err = createBaseWorktree(ctx, gitClient, detector, opts, affected, worktree, baseSHA)
+			if err != nil {
+				return err
+			}
+		}
+		defer func() {
+			if err := cleanup(); err != nil {
+				slog.Warn("worktree cleanup failed", "error", err)
+			}
+		}()
+		jobs := make([]engine.Job, 0, len(affected))
+		for _, p := range affected {
+			jobs = append(jobs, engine.Job{Path: p})
+		}
+		var remoteEnv []string
+		if opts.cacheRemoteBases {
+			remoteEnv, err = cache.MirrorRemotes(ctx, detector.RemoteRefs())
+			if err != nil {
+				return err
+			}
+		}
+		builder, err := newBuilder(ctx, opts, cfg.Plugins.Allow, remoteEnv)
+		if err != nil {
+			return err
+		}
+		vars, err := substitutionVars(headRoot, cfg)
+		if err != nil {
+			return err
+		}
+		eng := &engine.Engine{Builder: builder, Vars: vars, Concurrency: opts.concurrency, BuildTimeout: opts.buildTimeout, MemoryAware: opts.memoryThrottle, BaselineDir: opts.baselineDir, KeepRenders: opts.regoDir != "" || opts.kyvernoDir != "" || opts.recurseApps || opts.againstCluster != ""}
+		var caches tieredRenderCache
+		if opts.renderCache != "" {
+			dir := opts.renderCache
+			if dir == "auto" {
+				dir = ""
+			}
+			store, err := cache.OpenRenderStore(dir, builderFingerprint(opts))
+			if err != nil {
+				return err
+			}
+			caches = append(caches, store)
+		}
+		if opts.renderCacheURL != "" {
+			caches = append(caches, cache.NewHTTPRenderCache(opts.renderCacheURL, builderFingerprint(opts)))
+		}
+		switch len(caches) {
+		case 0:
+		case 1:
+			eng.RenderCache = caches[0]
+		default:
+			eng.RenderCache = caches
+		}
+		eng.IgnoreRules = func(p appset.ComponentPath) []appset.IgnoreRule {
+			rules, err := appset.IgnoreDifferences(headRoot, p)
+			if err != nil {
+				return nil
+			}
+			return rules
+		}
+		bar := newProgressBar()
+		if bar != nil {
+			eng.OnProgress = bar.update
+		}
+		result, err = eng.Run(ctx, worktree, headRoot, jobs)
+		if err != nil {
+			bar.finish()
+			return err
+		}
+		if opts.recurseApps {
+			if err := recurseApplications(ctx, eng, worktree, headRoot, result); err != nil {
+				bar.finish()
+				return err
+			}
+		}
+		bar.finish()
+		result.BaseRef, result.BaseSHA, result.HeadSHA = displayRef, baseSHA, headSHA
+		if opts.baselineDir == "" {
+			basePaths, err := discoverAll(worktree, opts.overlaysDirs)
+			if err != nil {
+				slog.Warn("base discovery failed, skipping removal tracking", "error", err)
+			} else {
+				result.Removals = detect.Removals(basePaths, paths)
+			}
+		}
+	}
+
+	if opts.againstCluster != "" {
+		if err := diffAgainstCluster(ctx, opts, result); err != nil {
+			return err
+		}
+	}
+	if opts.regoDir != "" {
+		violations, err := opa.FindingsFor(ctx, filepath.Join(headRoot, opts.regoDir), result)
+		if err != nil {
+			return err
+		}
+		result.Findings = append(result.Findings, violations...)
+	}
+	if opts.kyvernoDir != "" {
+		violations, err := kyverno.FindingsFor(ctx, opts.kyvernoBin, filepath.Join(headRoot, opts.kyvernoDir), result)
+		if err != nil {
+			return err
+		}
+		result.Findings = append(result.Findings, violations...)
+	}
+	result.Findings = append(result.Findings, analysis.RBACFindings(result)...)
+	result.Findings = append(result.Findings, analysis.SecurityContextFindings(result)...)
+	result.Findings = append(result.Findings, analysis.AppProjectFindings(result)...)
+	result.Findings = append(result.Findings, analysis.SyncOrderFindings(result)...)
+	result.Findings = append(result.Findings, analysis.PruneImpact(headRoot, result)...)
+	nsDeletions := analysis.NamespaceRemovals(result)
+	result.Findings = append(result.Findings, nsDeletions...)
+	crdRemovals := analysis.CRDRemovals(result)
+	result.Findings = append(result.Findings, crdRemovals...)
+	result.Findings = append(result.Findings, analysis.CapacityReductions(result)...)
+	result.Findings = append(result.Findings, analysis.ImageFindings(result, cfg.Images)...)
+	if src := capacitySource(opts); src != nil {
+		result.Findings = append(result.Findings, capacity.Check(ctx, src, result)...)
+	}
+	deprecated, err := deprecation.FindingsFor(headRoot, result)
+	if err != nil {
+		return err
+	}
+	result.Findings = append(result.Findings, deprecated...)
+	if opts.historyDir != "" {
+		if err := appendHistory(opts, result); err != nil {
+			slog.Warn("recording history failed", "error", err)
+		}
+	}
+	if opts.timingsFile != "" {
+		if err := writeTimings(opts.timingsFile, result); err != nil {
+			return err
+		}
+	}
+	if opts.comment {
+		if err := postComment(ctx, opts, result); err != nil {
+			return err
+		}
+	}
+	if opts.riskLabels {
+		if err := syncRiskLabels(ctx, opts, result); err != nil {
+			return err
+		}
+	}
+	if opts.requestReviews {
+		if err := requestComponentReviews(ctx, opts, headRoot, result); err != nil {
+			return err
+		}
+	}
+	if opts.checkPolicy != "" {
+		if err := setPolicyStatus(ctx, opts, result); err != nil {
+			return err
+		}
+	}
+	if opts.requireAck {
+		if err := setAckStatus(ctx, opts, result); err != nil {
+			return err
+		}
+	}
+	if err := emit(opts, result); err != nil {
+		return err
+	}
+	if opts.failOnBuildError && result.HasBuildErrors() {
+		failed := 0
+		for _, c := range result.Components {
+			if c.Error != "" {
+				failed++
+			}
+		}
+		return fmt.Errorf("%d component(s) failed to build", failed)
+	}
+	if opts.failOnViolations {
+		critical := 0
+		for _, f := range result.Findings {
+			if f.Severity == "critical" {
+				critical++
+			}
+		}
+		if critical > 0 {
+			return fmt.Errorf("%d critical policy finding(s)", critical)
+		}
+	}
+	if opts.failOnNSDeletion && len(nsDeletions) > 0 {
+		return fmt.Errorf("%d Namespace deletion(s) detected", len(nsDeletions))
+	}
+	if opts.maxDiffLines > 0 || opts.maxComponents > 0 {
+		var totalLines, changed int
+		for _, c := range result.Components {
+			if c.Diff != "" || c.Error != "" {
+				changed++
+			}
+			totalLines += c.Added + c.Removed
+		}
+		if opts.maxDiffLines > 0 && totalLines > opts.maxDiffLines {
+			return &thresholdExceededError{fmt.Sprintf("diff spans %d lines, over the --max-diff-lines threshold of %d", totalLines, opts.maxDiffLines)}
+		}
+		if opts.maxComponents > 0 && changed > opts.maxComponents {
+			return &thresholdExceededError{fmt.Sprintf("%d components changed, over the --max-components threshold of %d", changed, opts.maxComponents)}
+		}
+	}
+	if opts.failOnCRDRemoval && len(crdRemovals) > 0 {
+		overridden, err := crdRemovalOverridden(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if !overridden {
+			return fmt.Errorf("%d CustomResourceDefinition removal(s) detected (label the PR %q to override)", len(crdRemovals), opts.crdOverrideLabel)
+		}
+		slog.Info("CRD removal override label present, not failing", "label", opts.crdOverrideLabel)
+	}
+	return nil
+}
+
+// crdRemovalOverridden checks whether the PR carries the override label
+// that downgrades --fail-on-crd-removal to a finding. Without PR context
+// there is nothing to check and the failure stands.
+func crdRemovalOverridden(ctx context.Context, opts *options) (bool, error) {
+	if opts.githubRepo == "" || opts.prNumber == 0 {
+		return false, nil
+	}
+	gh, err := ghclientFromEnv(ctx, opts.githubRepo)
+	if err != nil {
+		return false, err
+	}
+	return gh.HasLabel(ctx, opts.prNumber, opts.crdOverrideLabel)
+}
+
+// baseRefFromCI resolves the base branch from CI-provided PR metadata, so
+// workflow steps do not have to compute and pass --base-ref themselves.
+// GitHub Actions sets GITHUB_BASE_REF on pull_request events and points
+// GITHUB_EVENT_PATH at the event payload; GitLab merge request pipelines set
+// CI_MERGE_REQUEST_TARGET_BRANCH_NAME.
+func baseRefFromCI() (ref, source string) {
+	if branch := os.Getenv("GITHUB_BASE_REF"); branch != "" {
+		return "origin/" + branch, "GITHUB_BASE_REF"
+	}
+	if path := os.Getenv("GITHUB_EVENT_PATH"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			var event struct {
+				PullRequest struct {
+					Base struct {
+						Ref string `json:"ref"`
+					} `json:"base"`
+				} `json:"pull_request"`
+			}
+			if json.Unmarshal(data, &event) == nil && event.PullRequest.Base.Ref != "" {
+				return "origin/" + event.PullRequest.Base.Ref, "GITHUB_EVENT_PATH"
+			}
+		}
+	}
+	if branch := os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"); branch != "" {
+		return "origin/" + branch, "CI_MERGE_REQUEST_TARGET_BRANCH_NAME"
+	}
+	return "", ""
+}
+
+// newBuilder picks the render backend: in-process krusty by default, the
+// kustomize binary when --kustomize-bin is set for repos that depend on
+// binary-only behavior. Age identities reach SOPS exec plugins through the
+// build environment either way.
+func newBuilder(ctx context.Context, opts *options, pluginAllow, extraEnv []string) (engine.RepoBuilder, error) {
+	env := append([]string(nil), extraEnv...)
+	if opts.ageKey != "" {
+		provider, err := agekey.FromSpec(opts.ageKey)
+		if err != nil {
+			return nil, err
+		}
+		ids, err := agekey.Load(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, "SOPS_AGE_KEY="+strings.Join(ids, "\n"))
+	}
+	bin := opts.kustomize
+	if opts.kustomizeVersion != "" {
+		if bin != "" {
+			return nil, fmt.Errorf("--kustomize-version and --kustomize-bin are mutually exclusive")
+		}
+		var err error
+		bin, err = cache.KustomizeBinary(ctx, opts.kustomizeVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if bin != "" {
+		return &engine.ExecBuilder{
+			Bin: bin, Env: env,
+			EnableHelm: opts.enableHelm, HelmBin: opts.helmBin,
+			EnablePlugins: opts.enablePlugins, PluginAllow: pluginAllow,
+			LoadRestrictor: opts.loadRestrictor, ExtraFlags: opts.buildFlags,
+			NoNetwork: opts.noNetwork,
+		}, nil
+	}
+	if len(opts.buildFlags) > 0 {
+		return nil, fmt.Errorf("--build-flag requires --kustomize-bin or --kustomize-version")
+	}
+	if opts.noNetwork {
+		// The in-process builder cannot enter a network namespace; poison
+		// the proxy environment so its git and helm fetches fail instead.
+		for _, kv := range engine.SandboxProxyEnv() {
+			key, value, _ := strings.Cut(kv, "=")
+			os.Setenv(key, value)
+		}
+	}
+	// Plugins spawned by the in-process builder inherit our environment.
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		os.Setenv(key, value)
+	}
+	return &engine.KrustyBuilder{
+		EnableHelm: opts.enableHelm, HelmBin: opts.helmBin,
+		EnablePlugins: opts.enablePlugins, PluginAllow: pluginAllow,
+		LoadRestrictor: opts.loadRestrictor,
+	}, nil
+}
+
+// tieredRenderCache chains render caches, fastest first: gets try each tier
+// in order and backfill the earlier tiers on a hit; puts go to every tier.
+type tieredRenderCache []engine.RenderCache
+
+func (t tieredRenderCache) Get(key string) (string, bool) {
+	for i, c := range t {
+		if render, ok := c.Get(key); ok {
+			for j := 0; j < i; j++ {
+				t[j].Put(key, render)
+			}
+			return render, true
+		}
+	}
+	return "", false
+}
+
+func (t tieredRenderCache) Put(key, render string) {
+	for _, c := range t {
+		c.Put(key, render)
+	}
+}
+
+// configureLogging applies --quiet and -v/-vv to the process-wide slog
+// level, so command output and library logging move together.
+func configureLogging(quiet bool, verbosity int) error {
+	if quiet && verbosity > 0 {
+		return fmt.Errorf("--quiet and --verbose are mutually exclusive")
+	}
+	level := slog.LevelInfo
+	switch {
+	case quiet:
+		level = slog.LevelError
+	case verbosity >= 1:
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level:     level,
+		AddSource: verbosity >= 2,
+	})))
+	return nil
+}
+
+// writeTimings dumps per-component build and diff durations as JSON,
+// slowest first, for tracking down where a run's time went.
+func writeTimings(path string, result *engine.DiffResult) error {
+	type timing struct {
+		Path        string `json:"path"`
+		Component   string `json:"component"`
+		Environment string `json:"environment,omitempty"`
+		BuildMillis int64  `json:"buildMillis"`
+		DiffMillis  int64  `json:"diffMillis"`
+	}
+	timings := make([]timing, 0, len(result.Components))
+	for _, c := range result.Components {
+		timings = append(timings, timing{
+			Path: c.Path.Path, Component: c.Path.Component, Environment: c.Path.Environment,
+			BuildMillis: c.BuildMillis, DiffMillis: c.DiffMillis,
+		})
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		return timings[i].BuildMillis+timings[i].DiffMillis > timings[j].BuildMillis+timings[j].DiffMillis
+	})
+	data, err := json.MarshalIndent(timings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// builderFingerprint namespaces the persistent render cache by every option
+// that changes build output for the same inputs, so switching builders or
+// build flags never serves stale renders.
+func builderFingerprint(opts *options) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		opts.kustomize,
+		opts.kustomizeVersion,
+		fmt.Sprintf("helm=%t:%s", opts.enableHelm, opts.helmBin),
+		fmt.Sprintf("plugins=%t", opts.enablePlugins),
+		opts.loadRestrictor,
+		strings.Join(opts.buildFlags, " "),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// substitutionVars loads the per-environment variable files configured under
+// substitute: in .render-diff.yaml, resolved against the head tree.
+func substitutionVars(headRoot string, cfg *config.Config) (map[string]map[string]string, error) {
+	if len(cfg.Substitute) == 0 {
+		return nil, nil
+	}
+	vars := map[string]map[string]string{}
+	for env, file := range cfg.Substitute {
+		loaded, err := envsubst.LoadVars(filepath.Join(headRoot, file))
+		if err != nil {
+			return nil, fmt.Errorf("loading substitution vars for %s: %w", env, err)
+		}
+		vars[env] = loaded
+	}
+	return vars, nil
+}
+
+// createBaseWorktree checks the base revision out into dir, restricted to
+// what rendering actually needs: the affected paths, their components and
+// dependencies, plus the overlays roots for removal tracking. It falls back
+// to a full checkout when sparse checkout is unavailable.
+func createBaseWorktree(ctx context.Context, gitClient git.Client, detector *detect.Detector, opts *options, affected []appset.ComponentPath, dir, baseSHA string) (func() error, error) {
+	sparse := map[string]bool{}
+	for _, d := range opts.overlaysDirs {
+		sparse[d] = true
+	}
+	for _, p := range affected {
+		sparse[p.Path] = true
+		sparse[p.Component] = true
+		for _, dep := range detector.Deps(p.Path) {
+			sparse[dep] = true
+		}
+	}
+	sparsePaths := make([]string, 0, len(sparse))
+	for p := range sparse {
+		sparsePaths = append(sparsePaths, p)
+	}
+	cleanup, err := gitClient.CreateSparseWorktree(ctx, dir, baseSHA, sparsePaths)
+	if err != nil {
+		slog.Warn("sparse worktree failed, falling back to full checkout", "error", err)
+		return gitClient.CreateWorktree(ctx, dir, baseSHA)
+	}
+	return cleanup, nil
+}
+
+// discoverAll discovers component paths under every configured overlays
+// root, so repos with split ApplicationSet trees (host vs member) are fully
+// covered by one run.
+func discoverAll(repoRoot string, overlaysDirs []string) ([]appset.ComponentPath, error) {
+	var paths []appset.ComponentPath
+	for _, dir := range overlaysDirs {
+		found, err := appset.Discover(repoRoot, dir)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, found...)
+	}
+	return paths, nil
+}
+
+func capacitySource(opts *options) capacity.Source {
+	switch {
+	case opts.capacityFile != "":
+		return &capacity.FileSource{Path: opts.capacityFile}
+	case opts.capacityEndpoint != "":
+		return &capacity.HTTPSource{Endpoint: opts.capacityEndpoint}
+	default:
+		return nil
+	}
+}
+
+func emit(opts *options, result *engine.DiffResult) error {
+	var out string
+	switch opts.output {
+	case "text":
+		if opts.quiet {
+			out = report.Summary(result)
+		} else {
+			out = report.Text(result)
+		}
+	case "markdown":
+		out = report.Markdown(result)
+	case "json":
+		path := opts.outputFile
+		if path == "" {
+			path = "result.json"
+		}
+		if err := report.WriteJSON(result, path); err != nil {
+			return err
+		}
+		// Also fan the verdict out per environment for pipeline stages
+		// that only care about one of them.
+		return report.WriteEnvResults(result, filepath.Dir(path))
+	default:
+		return fmt.Errorf("unknown output format %q", opts.output)
+	}
+	if opts.outputFile != "" {
+		return os.WriteFile(opts.outputFile, []byte(out), 0o644)
+	}
+	fmt.Print(out)
+	return nil
+}
+
+func postComment(ctx context.Context, opts *options, result *engine.DiffResult) error {
+	if opts.githubRepo == "" || opts.prNumber == 0 {
+		return fmt.Errorf("--github-comment requires --github-repo and --pr-number")
+	}
+	client, err := ghclientFromEnv(ctx, opts.githubRepo)
+	if err != nil {
+		return err
+	}
+	body := report.Markdown(result)
+	if opts.checklist {
+		body += report.Checklist(result)
+	}
+	if opts.prDescription {
+		return client.UpsertBodySection(ctx, opts.prNumber, report.BodySectionBegin, report.BodySectionEnd, body)
+	}
+	if !result.HasDiffs() && len(result.Removals) == 0 && len(result.Findings) == 0 {
+		// Only touch an existing comment: replacing its stale change
+		// table matters, but clean PRs need no comment at all.
+		body = fmt.Sprintf("No render differences detected (updated at %.12s).", result.HeadSHA)
+		return client.UpdateCommentIfExists(ctx, opts.prNumber, report.CommentMarker, body)
+	}
+	return client.UpsertComment(ctx, opts.prNumber, report.CommentMarker, body)
+}
+
+// syncRiskLabels reconciles the render-diff/* label namespace on the PR with
+// the current run, so labels applied on an earlier push disappear once a fix
+// clears the condition.
+func syncRiskLabels(ctx context.Context, opts *options, result *engine.DiffResult) error {
+	if opts.githubRepo == "" || opts.prNumber == 0 {
+		return fmt.Errorf("--risk-labels requires --github-repo and --pr-number")
+	}
+	client, err := ghclientFromEnv(ctx, opts.githubRepo)
+	if err != nil {
+		return err
+	}
+	var total int
+	var buildError, production bool
+	for _, c := range result.Components {
+		total += c.Added + c.Removed
+		if c.Error != "" {
+			buildError = true
+		}
+		if c.Path.Environment == "production" && (c.Diff != "" || c.Deleted) {
+			production = true
+		}
+	}
+	return client.SyncLabels(ctx, opts.prNumber, map[string]bool{
+		"render-diff/large":             total >= opts.largeDiffLines,
+		"render-diff/build-error":       buildError,
+		"render-diff/production-impact": production,
+	})
+}
+
+// requestComponentReviews pulls in the owners of every component whose
+// rendered output changed, per the OWNERS files in the head tree.
+func requestComponentReviews(ctx context.Context, opts *options, headRoot string, result *engine.DiffResult) error {
+	if opts.githubRepo == "" || opts.prNumber == 0 {
+		return fmt.Errorf("--request-reviews requires --github-repo and --pr-number")
+	}
+	var components []string
+	seen := map[string]bool{}
+	for _, c := range result.Components {
+		if c.Diff == "" && !c.Deleted && c.Error == "" {
+			continue
+		}
+		if !seen[c.Path.Component] {
+			seen[c.Path.Component] = true
+			components = append(components, c.Path.Component)
+		}
+	}
+	users, teams, err := owners.ReviewersFor(headRoot, components)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 && len(teams) == 0 {
+		return nil
+	}
+	client, err := ghclientFromEnv(ctx, opts.githubRepo)
+	if err != nil {
+		return err
+	}
+	slog.Info("requesting component owner reviews", "users", users, "teams", teams)
+	return client.RequestReviewers(ctx, opts.prNumber, users, teams)
+}
+
+// setPolicyStatus evaluates the check policy against the result and records
+// the conclusion as a commit status on the head SHA. GitHub statuses have no
+// neutral state, so neutral maps to success with an explanatory description.
+func setPolicyStatus(ctx context.Context, opts *options, result *engine.DiffResult) error {
+	if opts.githubRepo == "" {
+		return fmt.Errorf("--check-policy requires --github-repo")
+	}
+	pol, err := policy.Load(opts.checkPolicy)
+	if err != nil {
+		return err
+	}
+	conclusion, reason := pol.Evaluate(result)
+	state := conclusion
+	if conclusion == "neutral" {
+		state = "success"
+		reason = "neutral: " + reason
+	}
+	client, err := ghclientFromEnv(ctx, opts.githubRepo)
+	if err != nil {
+		return err
+	}
+	slog.Info("policy conclusion", "conclusion", conclusion, "reason", reason)
+	return client.SetStatus(ctx, result.HeadSHA, state, "render-diff/policy", reason)
+}
+
+// recurseApplications follows app-of-apps layers: component paths
+// referenced by Application resources in the rendered output are rendered
+// and diffed too, repeatedly, until no new paths appear. Depth is capped
+// to guard against reference cycles. Only head renders are inspected, so
+// children that exist solely on base are not followed.
+func recurseApplications(ctx context.Context, eng *engine.Engine, baseRoot, headRoot string, result *engine.DiffResult) error {
+	seen := map[string]bool{}
+	frontier := result.Components
+	for _, c := range frontier {
+		seen[c.Path.Path] = true
+	}
+	for depth := 0; depth < 5; depth++ {
+		var jobs []engine.Job
+		for _, c := range frontier {
+			for _, child := range appset.ChildPaths(c.HeadRender) {
+				if seen[child.Path] {
+					continue
+				}
+				seen[child.Path] = true
+				jobs = append(jobs, engine.Job{Path: child})
+			}
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		slog.Info("recursing into app-of-apps children", "depth", depth+1, "paths", len(jobs))
+		children, err := eng.Run(ctx, baseRoot, headRoot, jobs)
+		if err != nil {
+			return err
+		}
+		result.Components = append(result.Components, children.Components...)
+		frontier = children.Components
+	}
+	return fmt.Errorf("app-of-apps recursion did not converge after 5 levels")
+}
+
+// diffAgainstCluster replaces each component's diff with a comparison of
+// its head render against live cluster state, previewing the actual apply
+// impact rather than the delta from the base ref.
+func diffAgainstCluster(ctx context.Context, opts *options, result *engine.DiffResult) error {
+	kubeconfig := opts.againstCluster
+	if kubeconfig == "ambient" {
+		kubeconfig = ""
+	}
+	for i := range result.Components {
+		c := &result.Components[i]
+		if c.Error != "" || c.HeadRender == "" {
+			continue
+		}
+		live, err := cluster.LiveRender(ctx, kubeconfig, c.HeadRender)
+		if err != nil {
+			c.Error = err.Error()
+			continue
+		}
+		diff, err := diffutil.Unified(c.Path.Path, live, c.HeadRender)
+		if err != nil {
+			return err
+		}
+		c.Diff = diff
+		c.Added, c.Removed = diffutil.Stats(diff)
+		c.Deleted = false
+	}
+	result.BaseRef = "live cluster"
+	return nil
+}
+
+// setAckStatus records the render-diff/ack commit status: success when
+// there are no findings or a reviewer has acknowledged them (the ack label
+// or a "/render-diff approve" comment), pending until then.
+func setAckStatus(ctx context.Context, opts *options, result *engine.DiffResult) error {
+	if opts.githubRepo == "" || opts.prNumber == 0 {
+		return fmt.Errorf("--require-ack requires --github-repo and --pr-number")
+	}
+	client, err := ghclientFromEnv(ctx, opts.githubRepo)
+	if err != nil {
+		return err
+	}
+	const statusContext = "render-diff/ack"
+	if len(result.Findings) == 0 {
+		return client.SetStatus(ctx, result.HeadSHA, "success", statusContext, "no findings to acknowledge")
+	}
+	acked, err := client.Acknowledged(ctx, opts.prNumber, opts.ackLabel)
+	if err != nil {
+		return err
+	}
+	if acked {
+		return client.SetStatus(ctx, result.HeadSHA, "success", statusContext, fmt.Sprintf("%d finding(s) acknowledged", len(result.Findings)))
+	}
+	description := fmt.Sprintf("%d finding(s) need the %s label or a /render-diff approve comment", len(result.Findings), opts.ackLabel)
+	return client.SetStatus(ctx, result.HeadSHA, "pending", statusContext, description)
+}
+
+func appendHistory(opts *options, result *engine.DiffResult) error {
+	entry := history.Entry{
+		PR:        opts.prNumber,
+		HeadSHA:   result.HeadSHA,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, c := range result.Components {
+		entry.Components = append(entry.Components, history.ComponentRecord{
+			Component:   c.Path.Component,
+			Environment: c.Path.Environment,
+			ClusterDir:  c.Path.ClusterDir,
+			Added:       c.Added,
+			Removed:     c.Removed,
+			HasDiff:     c.Diff != "",
+		})
+	}
+	return history.NewStore(filepath.Clean(opts.historyDir)).Append(entry)
+}
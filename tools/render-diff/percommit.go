@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// runPerCommit walks every commit between base and head, diffs each one
+// against its parent and reports which commit introduced each component
+// change — useful for bisecting the rendered impact of a large PR.
+func runPerCommit(ctx context.Context, opts *options, gitClient git.Client, baseSHA, headSHA string) error {
+	commits, err := gitClient.Commits(ctx, baseSHA, headSHA)
+	if err != nil {
+		return err
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Per-commit render delta, %.12s..%.12s (%d commits)\n", baseSHA, headSHA, len(commits))
+	// introduced records the first commit that changed each rendered path.
+	introduced := map[string]string{}
+	prev := baseSHA
+	for _, sha := range commits {
+		if err := diffOneCommit(ctx, opts, gitClient, prev, sha, &buf, introduced); err != nil {
+			return err
+		}
+		prev = sha
+	}
+	if len(introduced) > 0 {
+		paths := make([]string, 0, len(introduced))
+		for p := range introduced {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		buf.WriteString("\nFirst change per component path:\n")
+		for _, p := range paths {
+			fmt.Fprintf(&buf, "  %.12s  %s\n", introduced[p], p)
+		}
+	}
+	if opts.outputFile != "" {
+		return os.WriteFile(opts.outputFile, []byte(buf.String()), 0o644)
+	}
+	fmt.Print(buf.String())
+	return nil
+}
+
+// diffOneCommit renders the delta a single commit introduces over its
+// predecessor and appends a section for it to buf.
+func diffOneCommit(ctx context.Context, opts *options, gitClient git.Client, prev, sha string, buf *strings.Builder, introduced map[string]string) error {
+	changed, err := gitClient.ChangedFiles(ctx, prev, sha)
+	if err != nil {
+		return err
+	}
+	headDir, headCleanup, err := tempWorktree(ctx, gitClient, sha)
+	if err != nil {
+		return err
+	}
+	defer headCleanup()
+	paths, err := discoverAll(headDir, opts.overlaysDirs)
+	if err != nil {
+		return err
+	}
+	detector := detect.New(paths)
+	if err := detector.ScanKustomizeRefs(headDir); err != nil {
+		slog.Warn("kustomization scan failed", "commit", sha, "error", err)
+	}
+	affected := detect.Filter(detector.Affected(changed), opts.include, opts.exclude)
+	fmt.Fprintf(buf, "\ncommit %.12s (%d files, %d affected paths)\n", sha, len(changed), len(affected))
+	if len(affected) == 0 {
+		return nil
+	}
+	baseDir, baseCleanup, err := tempWorktree(ctx, gitClient, prev)
+	if err != nil {
+		return err
+	}
+	defer baseCleanup()
+	jobs := make([]engine.Job, 0, len(affected))
+	for _, p := range affected {
+		jobs = append(jobs, engine.Job{Path: p})
+	}
+	cfg, err := config.Load(headDir)
+	if err != nil {
+		return err
+	}
+	builder, err := newBuilder(ctx, opts, cfg.Plugins.Allow, nil)
+	if err != nil {
+		return err
+	}
+	eng := &engine.Engine{Builder: builder, BuildTimeout: opts.buildTimeout}
+	result, err := eng.Run(ctx, baseDir, headDir, jobs)
+	if err != nil {
+		return err
+	}
+	for _, c := range result.Components {
+		switch {
+		case c.Deleted:
+			fmt.Fprintf(buf, "  - %s deleted\n", c.Path.Path)
+		case c.Diff != "":
+			fmt.Fprintf(buf, "  ~ %s (+%d -%d)\n", c.Path.Path, c.Added, c.Removed)
+		default:
+			continue
+		}
+		if introduced[c.Path.Path] == "" {
+			introduced[c.Path.Path] = sha
+		}
+	}
+	return nil
+}
+
+// tempWorktree checks ref out into a fresh temporary directory.
+func tempWorktree(ctx context.Context, gitClient git.Client, ref string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "render-diff-commit-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup, err := gitClient.CreateWorktree(ctx, dir, ref)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	return dir, func() {
+		if err := cleanup(); err != nil {
+			slog.Warn("worktree cleanup failed", "dir", dir, "error", err)
+		}
+	}, nil
+}
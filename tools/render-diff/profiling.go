@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"runtime/pprof"
+
+	"github.com/spf13/cobra"
+)
+
+// addProfiling registers the --pprof-addr and --cpuprofile persistent flags
+// and hooks them around every command, so a slow run can be broken down
+// into git, kustomize and diff time with standard Go tooling.
+func addProfiling(cmd *cobra.Command) {
+	var (
+		pprofAddr  string
+		cpuProfile string
+		profileOut *os.File
+	)
+	cmd.PersistentFlags().StringVar(&pprofAddr, "pprof-addr", "", "serve net/http/pprof on this address for live profiling")
+	cmd.PersistentFlags().StringVar(&cpuProfile, "cpuprofile", "", "write a CPU profile of the run to this file")
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if pprofAddr != "" {
+			go func() {
+				if err := http.ListenAndServe(pprofAddr, nil); err != nil {
+					slog.Warn("pprof server failed", "addr", pprofAddr, "error", err)
+				}
+			}()
+		}
+		if cpuProfile != "" {
+			f, err := os.Create(cpuProfile)
+			if err != nil {
+				return err
+			}
+			if err := pprof.StartCPUProfile(f); err != nil {
+				f.Close()
+				return fmt.Errorf("starting CPU profile: %w", err)
+			}
+			profileOut = f
+		}
+		return nil
+	}
+	cmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		if profileOut != nil {
+			pprof.StopCPUProfile()
+			profileOut.Close()
+		}
+	}
+}
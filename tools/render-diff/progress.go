@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// progressBar redraws a one-line "N/M components" indicator with elapsed
+// time and an ETA on stderr while builds run, so local runs are not silent
+// until the first diff appears. It is disabled when stderr is not a
+// terminal, keeping CI logs free of carriage returns.
+type progressBar struct {
+	mu    sync.Mutex
+	start time.Time
+	width int
+}
+
+// newProgressBar returns nil when stderr is not a terminal; the methods
+// treat a nil receiver as a no-op, so call sites need no guards.
+func newProgressBar() *progressBar {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressBar{start: time.Now()}
+}
+
+// update renders the current state; it is wired as Engine.OnProgress.
+func (p *progressBar) update(pr engine.Progress) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	total := pr.Queued + pr.Running + pr.Done
+	line := fmt.Sprintf("%d/%d components  %s elapsed", pr.Done, total, time.Since(p.start).Round(time.Second))
+	if pr.Done > 0 && pr.Done < total {
+		remaining := time.Duration(float64(time.Since(p.start)) / float64(pr.Done) * float64(total-pr.Done))
+		line += fmt.Sprintf("  ETA %s", remaining.Round(time.Second))
+	}
+	if pr.Component != "" {
+		line += "  " + pr.Component
+	}
+	if len(line) > 120 {
+		line = line[:120]
+	}
+	p.draw(line)
+}
+
+// finish clears the indicator line before regular output takes over.
+func (p *progressBar) finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draw("")
+}
+
+// draw rewrites the current line, padding over whatever was there before.
+func (p *progressBar) draw(line string) {
+	pad := ""
+	if p.width > len(line) {
+		pad = strings.Repeat(" ", p.width-len(line))
+	}
+	fmt.Fprintf(os.Stderr, "\r%s%s", line, pad)
+	p.width = len(line)
+}
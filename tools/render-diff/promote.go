@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/appset"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/diffutil"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newPromoteCmd builds the "promote" subcommand, a promotion assistant:
+// for one component it renders the source environment (staging) against
+// the target (production) so the pending rollout delta is visible, and can
+// scaffold overlay files the target is missing.
+func newPromoteCmd() *cobra.Command {
+	var (
+		component    string
+		from         string
+		to           string
+		scaffold     bool
+		overlaysDirs []string
+	)
+	cmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Show the rendered delta between two environments of a component",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			root, err := gitClient.TopLevel(ctx)
+			if err != nil {
+				return err
+			}
+			paths, err := discoverAll(root, overlaysDirs)
+			if err != nil {
+				return err
+			}
+			var fromPaths, toPaths []appset.ComponentPath
+			for _, p := range paths {
+				if p.Component != component {
+					continue
+				}
+				switch p.Environment {
+				case from:
+					fromPaths = append(fromPaths, p)
+				case to:
+					toPaths = append(toPaths, p)
+				}
+			}
+			if len(fromPaths) == 0 {
+				return fmt.Errorf("no %s paths found for component %s", from, component)
+			}
+			if len(toPaths) == 0 {
+				return fmt.Errorf("no %s paths found for component %s", to, component)
+			}
+			builder := &engine.KrustyBuilder{}
+			render := func(path string) (string, error) {
+				rendered, err := builder.Build(ctx, root, path)
+				if err != nil {
+					return "", err
+				}
+				return diffutil.Normalize(rendered)
+			}
+			out := cmd.OutOrStdout()
+			for _, target := range toPaths {
+				source := matchOverlay(fromPaths, target.ClusterDir)
+				fromRender, err := render(source.Path)
+				if err != nil {
+					return fmt.Errorf("rendering %s: %w", source.Path, err)
+				}
+				toRender, err := render(target.Path)
+				if err != nil {
+					return fmt.Errorf("rendering %s: %w", target.Path, err)
+				}
+				diff, err := diffutil.Unified(target.Path, toRender, fromRender)
+				if err != nil {
+					return err
+				}
+				if diff == "" {
+					fmt.Fprintf(out, "=== %s already matches %s\n", target.Path, source.Path)
+					continue
+				}
+				added, removed := diffutil.Stats(diff)
+				fmt.Fprintf(out, "=== %s vs %s (+%d -%d; additions are what %s gains)\n%s\n", target.Path, source.Path, added, removed, to, diff)
+			}
+			if !scaffold {
+				return nil
+			}
+			for _, target := range toPaths {
+				source := matchOverlay(fromPaths, target.ClusterDir)
+				copied, err := scaffoldOverlay(filepath.Join(root, source.Path), filepath.Join(root, target.Path))
+				if err != nil {
+					return err
+				}
+				for _, name := range copied {
+					fmt.Fprintf(out, "scaffolded %s\n", filepath.Join(target.Path, name))
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&component, "component", "", "component directory, e.g. components/spi")
+	cmd.Flags().StringVar(&from, "from", "staging", "environment to promote from")
+	cmd.Flags().StringVar(&to, "to", "production", "environment to promote into")
+	cmd.Flags().BoolVar(&scaffold, "scaffold", false, "copy overlay files the target environment is missing from the source")
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	cmd.MarkFlagRequired("component")
+	return cmd
+}
+
+// matchOverlay picks the source overlay matching a target cluster dir,
+// falling back to the environment-wide "base" overlay, then to the first.
+func matchOverlay(candidates []appset.ComponentPath, clusterDir string) appset.ComponentPath {
+	for _, c := range candidates {
+		if c.ClusterDir == clusterDir {
+			return c
+		}
+	}
+	for _, c := range candidates {
+		if c.ClusterDir == "base" {
+			return c
+		}
+	}
+	return candidates[0]
+}
+
+// scaffoldOverlay copies files present in the source overlay directory but
+// absent from the target, returning the copied names. Existing target
+// files are never overwritten — the diff output is the guide for editing
+// those.
+func scaffoldOverlay(sourceDir, targetDir string) ([]string, error) {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+	var copied []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		targetPath := filepath.Join(targetDir, name)
+		if _, err := os.Stat(targetPath); err == nil {
+			continue
+		}
+		src, err := os.Open(filepath.Join(sourceDir, name))
+		if err != nil {
+			return nil, err
+		}
+		dst, err := os.Create(targetPath)
+		if err != nil {
+			src.Close()
+			return nil, err
+		}
+		_, err = io.Copy(dst, src)
+		src.Close()
+		if cerr := dst.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, err
+		}
+		copied = append(copied, name)
+	}
+	return copied, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/report"
+)
+
+// newPublishCmd builds the "publish" subcommand, which re-posts the PR
+// comment from a previously computed result without re-rendering anything —
+// so transient GitHub API failures can be retried cheaply in a follow-up
+// job.
+func newPublishCmd() *cobra.Command {
+	var (
+		from       string
+		githubRepo string
+		prNumber   int
+	)
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish GitHub outputs from a stored result without re-rendering",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if githubRepo == "" || prNumber == 0 {
+				return fmt.Errorf("publish requires --github-repo and --pr-number")
+			}
+			result, err := loadResult(from)
+			if err != nil {
+				return err
+			}
+			client, err := ghclientFromEnv(cmd.Context(), githubRepo)
+			if err != nil {
+				return err
+			}
+			return client.UpsertComment(cmd.Context(), prNumber, report.CommentMarker, report.Markdown(result))
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "result.json file or artifact directory containing one")
+	cmd.Flags().StringVar(&githubRepo, "github-repo", "", "GitHub repository (owner/repo)")
+	cmd.Flags().IntVar(&prNumber, "pr-number", 0, "pull request number")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
+
+// loadResult reads a DiffResult from a result.json file or a directory
+// holding one.
+func loadResult(from string) (*engine.DiffResult, error) {
+	info, err := os.Stat(from)
+	if err != nil {
+		return nil, err
+	}
+	path := from
+	if info.IsDir() {
+		path = filepath.Join(from, "result.json")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var result engine.DiffResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newRenderAllCmd builds the "render-all" subcommand, which renders every
+// discovered component at a ref into a deterministic snapshot tree — one
+// normalized YAML file per component path — suitable for committing,
+// archiving or later comparison.
+func newRenderAllCmd() *cobra.Command {
+	var (
+		ref          string
+		out          string
+		overlaysDirs []string
+		kustomizeBin string
+		enableHelm   bool
+		helmBin      string
+	)
+	cmd := &cobra.Command{
+		Use:   "render-all",
+		Short: "Render every component at a ref into a snapshot directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			root, err := gitClient.TopLevel(ctx)
+			if err != nil {
+				return err
+			}
+			if ref != "HEAD" {
+				sha, err := gitClient.ResolveRef(ctx, ref)
+				if err != nil {
+					return err
+				}
+				dir, cleanup, err := tempWorktree(ctx, gitClient, sha)
+				if err != nil {
+					return err
+				}
+				defer cleanup()
+				root = dir
+			}
+			paths, err := discoverAll(root, overlaysDirs)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.Load(root)
+			if err != nil {
+				return err
+			}
+			vars, err := substitutionVars(root, cfg)
+			if err != nil {
+				return err
+			}
+			var builder engine.RepoBuilder = &engine.KrustyBuilder{EnableHelm: enableHelm, HelmBin: helmBin}
+			if kustomizeBin != "" {
+				builder = &engine.ExecBuilder{Bin: kustomizeBin, EnableHelm: enableHelm, HelmBin: helmBin}
+			}
+			eng := &engine.Engine{Builder: builder, Vars: vars, KeepRenders: true}
+			jobs := make([]engine.Job, 0, len(paths))
+			for _, p := range paths {
+				jobs = append(jobs, engine.Job{Path: p})
+			}
+			// Rendering the same tree on both sides yields empty diffs
+			// and one kept render per component.
+			result, err := eng.Run(ctx, root, root, jobs)
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for _, c := range result.Components {
+				if c.Error != "" {
+					fmt.Fprintf(os.Stderr, "ERROR %s: %s\n", c.Path.Path, c.Error)
+					failed++
+					continue
+				}
+				target := filepath.Join(out, c.Path.Path+".yaml")
+				if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+					return err
+				}
+				if err := os.WriteFile(target, []byte(c.HeadRender), 0o644); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("wrote %d render(s) to %s\n", len(result.Components)-failed, out)
+			if failed > 0 {
+				return fmt.Errorf("%d component(s) failed to render", failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&ref, "ref", "HEAD", "git ref to render")
+	cmd.Flags().StringVar(&out, "out", "", "directory to write the snapshot tree into")
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	cmd.Flags().StringVar(&kustomizeBin, "kustomize-bin", "", "kustomize binary to shell out to instead of the built-in renderer")
+	cmd.Flags().BoolVar(&enableHelm, "enable-helm", false, "inflate helmCharts entries during builds instead of failing on them")
+	cmd.Flags().StringVar(&helmBin, "helm-bin", "", "helm binary used for chart inflation (default: helm on PATH)")
+	cmd.MarkFlagRequired("out")
+	return cmd
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/deprecation"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/ghclient"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/history"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/report"
+)
+
+// newReportCmd builds the "report" subcommand, which digests the history
+// store into a list of recurring noisy components and can keep a tracking
+// issue up to date with the findings.
+func newReportCmd() *cobra.Command {
+	var (
+		historyDir string
+		minRate    float64
+		minPRs     int
+		fileIssue  bool
+		githubRepo string
+	)
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Digest the history store for recurring noisy components",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := history.NewStore(historyDir).Entries()
+			if err != nil {
+				return err
+			}
+			noisy := report.Noisy(entries, minRate, minPRs)
+			digest := report.NoisyDigest(noisy)
+			fmt.Print(digest)
+			if !fileIssue {
+				return nil
+			}
+			if githubRepo == "" {
+				return fmt.Errorf("--file-issue requires --github-repo")
+			}
+			client, err := ghclientFromEnv(cmd.Context(), githubRepo)
+			if err != nil {
+				return err
+			}
+			url, err := client.UpsertIssue(cmd.Context(),
+				"render-diff: recurring noisy components",
+				report.NoisyIssueMarker, digest,
+				[]string{"render-diff"})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "tracking issue:", url)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&historyDir, "history-dir", ".render-diff/history", "history store directory")
+	cmd.Flags().Float64Var(&minRate, "min-rate", 0.5, "minimum share of PRs a component must diff in to be reported")
+	cmd.Flags().IntVar(&minPRs, "min-prs", 10, "minimum number of recorded PRs before reporting anything")
+	cmd.Flags().BoolVar(&fileIssue, "file-issue", false, "create or update the tracking issue on GitHub")
+	cmd.Flags().StringVar(&githubRepo, "github-repo", "", "GitHub repository (owner/repo) for the tracking issue")
+	cmd.AddCommand(newReportDeprecationsCmd())
+	return cmd
+}
+
+// newReportDeprecationsCmd lists every deprecated component that still
+// produces renders, with its migration target and sunset date.
+func newReportDeprecationsCmd() *cobra.Command {
+	var overlaysDirs []string
+	cmd := &cobra.Command{
+		Use:   "deprecations",
+		Short: "List deprecated components still producing renders",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoRoot, err := git.New(".").TopLevel(cmd.Context())
+			if err != nil {
+				return err
+			}
+			paths, err := discoverAll(repoRoot, overlaysDirs)
+			if err != nil {
+				return err
+			}
+			active, err := deprecation.Active(repoRoot, paths)
+			if err != nil {
+				return err
+			}
+			if len(active) == 0 {
+				fmt.Println("No deprecated components are still rendering.")
+				return nil
+			}
+			for _, component := range deprecation.Components(active) {
+				n := active[component]
+				fmt.Printf("%s", component)
+				if n.MigrateTo != "" {
+					fmt.Printf(" -> %s", n.MigrateTo)
+				}
+				if n.Sunset != "" {
+					fmt.Printf(" (sunset %s)", n.Sunset)
+				}
+				fmt.Println()
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	return cmd
+}
+
+// ghclientFromEnv builds a GitHub client from the environment. App
+// credentials (GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and
+// GITHUB_APP_PRIVATE_KEY holding either the PEM itself or a path to it) take
+// precedence so a dedicated bot identity can post; GITHUB_TOKEN is the
+// fallback.
+func ghclientFromEnv(ctx context.Context, ownerRepo string) (*ghclient.Client, error) {
+	token, err := githubTokenFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ghclient.New(ctx, token, ownerRepo)
+}
+
+func githubTokenFromEnv(ctx context.Context) (string, error) {
+	appID := os.Getenv("GITHUB_APP_ID")
+	if appID == "" {
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			return "", fmt.Errorf("neither GITHUB_APP_ID nor GITHUB_TOKEN is set")
+		}
+		return token, nil
+	}
+	id, err := strconv.ParseInt(appID, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid GITHUB_APP_ID %q: %w", appID, err)
+	}
+	installation, err := strconv.ParseInt(os.Getenv("GITHUB_APP_INSTALLATION_ID"), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID %q: %w", os.Getenv("GITHUB_APP_INSTALLATION_ID"), err)
+	}
+	key := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if !strings.Contains(string(key), "PRIVATE KEY") {
+		key, err = os.ReadFile(string(key))
+		if err != nil {
+			return "", fmt.Errorf("reading GITHUB_APP_PRIVATE_KEY file: %w", err)
+		}
+	}
+	return ghclient.InstallationToken(ctx, id, installation, key)
+}
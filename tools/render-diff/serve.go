@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/detect"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newServeCmd builds the "serve" subcommand: a local HTTP server that
+// shows the current branch's render diff as an interactive page —
+// component list with search, collapsible per-component diffs — and
+// recomputes the diff on every refresh, so it tracks the working branch
+// while you edit.
+func newServeCmd() *cobra.Command {
+	var (
+		addr         string
+		baseRef      string
+		overlaysDirs []string
+	)
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve an interactive render-diff page for the current branch",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/" {
+					http.NotFound(w, r)
+					return
+				}
+				result, err := computeBranchDiff(r.Context(), baseRef, overlaysDirs)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				if err := servePage.Execute(w, result); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+			})
+			mux.HandleFunc("/result.json", func(w http.ResponseWriter, r *http.Request) {
+				result, err := computeBranchDiff(r.Context(), baseRef, overlaysDirs)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(result)
+			})
+			fmt.Printf("serving render diff on http://%s\n", addr)
+			server := &http.Server{Addr: addr, Handler: mux}
+			go func() {
+				<-cmd.Context().Done()
+				server.Close()
+			}()
+			err := server.ListenAndServe()
+			if err == http.ErrServerClosed {
+				return nil
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8417", "address to listen on")
+	cmd.Flags().StringVar(&baseRef, "base-ref", "", "base ref to diff against (default: detected)")
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	return cmd
+}
+
+// computeBranchDiff renders the working tree's diff against the base ref,
+// the same comparison the root command makes but with default build
+// settings, fresh on every call.
+func computeBranchDiff(ctx context.Context, baseRef string, overlaysDirs []string) (*engine.DiffResult, error) {
+	gitClient := git.New(".")
+	root, err := gitClient.TopLevel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headSHA, err := gitClient.ResolveRef(ctx, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if baseRef == "" {
+		baseRef = git.DefaultBaseRef(ctx, gitClient)
+	}
+	baseSHA, err := git.MergeBaseWithFetch(ctx, gitClient, baseRef, headSHA)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := gitClient.ChangedFiles(ctx, baseSHA, headSHA)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := discoverAll(root, overlaysDirs)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load(root)
+	if err != nil {
+		return nil, err
+	}
+	detector := detect.New(paths)
+	if err := detector.ResolveSymlinks(root); err != nil {
+		return nil, err
+	}
+	if err := detector.ScanKustomizeRefs(root); err != nil {
+		return nil, err
+	}
+	detector.AddMappings(cfg.Mappings)
+	affected := detector.Affected(changed)
+	baseDir, cleanup, err := tempWorktree(ctx, gitClient, baseSHA)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	vars, err := substitutionVars(root, cfg)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]engine.Job, 0, len(affected))
+	for _, p := range affected {
+		jobs = append(jobs, engine.Job{Path: p})
+	}
+	eng := &engine.Engine{Builder: &engine.KrustyBuilder{}, Vars: vars}
+	result, err := eng.Run(ctx, baseDir, root, jobs)
+	if err != nil {
+		return nil, err
+	}
+	result.BaseRef, result.BaseSHA, result.HeadSHA = baseRef, baseSHA, headSHA
+	return result, nil
+}
+
+var servePage = template.Must(template.New("page").Funcs(template.FuncMap{
+	"short": func(s string) string {
+		if len(s) > 12 {
+			return s[:12]
+		}
+		return s
+	},
+	"diffLines": func(diff string) []string { return strings.Split(diff, "\n") },
+}).Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>render-diff</title>
+<style>
+body { font-family: sans-serif; margin: 1rem 2rem; }
+input { width: 24rem; padding: 0.3rem; margin-bottom: 1rem; }
+details { margin-bottom: 0.5rem; }
+summary { cursor: pointer; font-family: monospace; }
+pre { background: #f6f8fa; padding: 0.6rem; overflow-x: auto; font-size: 0.85rem; }
+.add { color: #116329; }
+.del { color: #82071e; }
+.error { color: #82071e; font-weight: bold; }
+.meta { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>Render diff</h1>
+<p class="meta">Comparing <code>{{.BaseRef}}</code> ({{short .BaseSHA}}) with {{short .HeadSHA}}. Refresh to recompute.</p>
+<input id="filter" placeholder="filter components..." oninput="filter()">
+{{range .Components}}{{if or .Diff .Error}}
+<details class="component" data-path="{{.Path.Path}}">
+<summary>{{.Path.Path}}{{if .Error}} <span class="error">build error</span>{{else}} <span class="meta">+{{.Added}} -{{.Removed}}</span>{{end}}</summary>
+{{if .Error}}<pre>{{.Error}}</pre>{{else}}<pre>{{range diffLines .Diff}}<span class="{{if eq (printf "%.1s" .) "+"}}add{{else if eq (printf "%.1s" .) "-"}}del{{end}}">{{.}}</span>
+{{end}}</pre>{{end}}
+</details>
+{{end}}{{end}}
+<script>
+function filter() {
+  const q = document.getElementById('filter').value.toLowerCase();
+  for (const d of document.querySelectorAll('.component')) {
+    d.style.display = d.dataset.path.toLowerCase().includes(q) ? '' : 'none';
+  }
+}
+</script>
+</body>
+</html>
+`))
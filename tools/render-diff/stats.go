@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/config"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/git"
+)
+
+// newStatsCmd builds the "stats" subcommand: render everything and report
+// size metrics — resources and bytes per component, totals per
+// environment, the largest ConfigMaps — so maintainers can watch repo
+// growth over time.
+func newStatsCmd() *cobra.Command {
+	var (
+		overlaysDirs []string
+		top          int
+	)
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report size metrics across all rendered components",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			gitClient := git.New(".")
+			root, err := gitClient.TopLevel(ctx)
+			if err != nil {
+				return err
+			}
+			paths, err := discoverAll(root, overlaysDirs)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.Load(root)
+			if err != nil {
+				return err
+			}
+			vars, err := substitutionVars(root, cfg)
+			if err != nil {
+				return err
+			}
+			jobs := make([]engine.Job, 0, len(paths))
+			for _, p := range paths {
+				jobs = append(jobs, engine.Job{Path: p})
+			}
+			eng := &engine.Engine{Builder: &engine.KrustyBuilder{}, Vars: vars, KeepRenders: true}
+			result, err := eng.Run(ctx, root, root, jobs)
+			if err != nil {
+				return err
+			}
+			type configMap struct {
+				name  string
+				where string
+				bytes int
+			}
+			envBytes := map[string]int{}
+			var configMaps []configMap
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "%-70s %10s %10s\n", "COMPONENT PATH", "RESOURCES", "BYTES")
+			failed := 0
+			for _, c := range result.Components {
+				if c.Error != "" {
+					failed++
+					continue
+				}
+				resources := 0
+				for _, doc := range strings.Split(c.HeadRender, "\n---\n") {
+					doc = strings.TrimSpace(doc)
+					if doc == "" {
+						continue
+					}
+					resources++
+					var parsed struct {
+						Kind     string `yaml:"kind"`
+						Metadata struct {
+							Name string `yaml:"name"`
+						} `yaml:"metadata"`
+					}
+					if yaml.Unmarshal([]byte(doc), &parsed) == nil && parsed.Kind == "ConfigMap" {
+						configMaps = append(configMaps, configMap{parsed.Metadata.Name, c.Path.Path, len(doc)})
+					}
+				}
+				env := c.Path.Environment
+				if env == "" {
+					env = "(none)"
+				}
+				envBytes[env] += len(c.HeadRender)
+				fmt.Fprintf(out, "%-70s %10d %10d\n", c.Path.Path, resources, len(c.HeadRender))
+			}
+			fmt.Fprintf(out, "\nBytes per environment:\n")
+			envs := make([]string, 0, len(envBytes))
+			for env := range envBytes {
+				envs = append(envs, env)
+			}
+			sort.Strings(envs)
+			for _, env := range envs {
+				fmt.Fprintf(out, "  %-20s %10d\n", env, envBytes[env])
+			}
+			sort.Slice(configMaps, func(i, j int) bool { return configMaps[i].bytes > configMaps[j].bytes })
+			if len(configMaps) > top {
+				configMaps = configMaps[:top]
+			}
+			if len(configMaps) > 0 {
+				fmt.Fprintf(out, "\nLargest ConfigMaps:\n")
+				for _, cm := range configMaps {
+					fmt.Fprintf(out, "  %10d  %s (%s)\n", cm.bytes, cm.name, cm.where)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d component(s) failed to render", failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&overlaysDirs, "overlays-dir", []string{"argo-cd-apps"}, "directories holding ApplicationSet definitions (repeatable or comma-separated)")
+	cmd.Flags().IntVar(&top, "top", 10, "how many of the largest ConfigMaps to list")
+	return cmd
+}
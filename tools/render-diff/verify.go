@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/analysis"
+	"github.com/redhat-appstudio/infra-deployments/tools/render-diff/internal/engine"
+)
+
+// Exit codes verify uses so CI can branch on the failure class rather
+// than parsing output.
+const (
+	exitVerifySize      = 4
+	exitVerifyForbidden = 5
+	exitVerifyFindings  = 6
+)
+
+// verifyPolicy is the schema of the verification policy file.
+type verifyPolicy struct {
+	// MaxDiffLines and MaxComponents bound the size of the change; zero
+	// disables the bound.
+	MaxDiffLines  int `yaml:"maxDiffLines"`
+	MaxComponents int `yaml:"maxComponents"`
+	// Forbid lists change classes that fail verification outright:
+	// build-error, namespace-deletion, crd-removal, production-deletion.
+	Forbid []string `yaml:"forbid"`
+	// FailOnSeverity fails verification when any finding reaches this
+	// severity ("warning" or "critical"); empty disables the check.
+	FailOnSeverity string `yaml:"failOnSeverity"`
+}
+
+// exitCodeError carries a dedicated process exit code with the failure.
+type exitCodeError struct {
+	code int
+	msg  string
+}
+
+func (e *exitCodeError) Error() string { return e.msg }
+
+// newVerifyCmd builds the "verify" subcommand: a CI gate that evaluates a
+// stored result against a verification policy and exits with a
+// class-specific code — distinct from the informational comment, so it can
+// back a required status check.
+func newVerifyCmd() *cobra.Command {
+	var (
+		from       string
+		policyFile string
+	)
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Gate a stored result against a verification policy",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := loadResult(from)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(policyFile)
+			if err != nil {
+				return err
+			}
+			var pol verifyPolicy
+			if err := yaml.Unmarshal(data, &pol); err != nil {
+				return fmt.Errorf("parsing %s: %w", policyFile, err)
+			}
+			if err := verifyResult(result, pol); err != nil {
+				return err
+			}
+			fmt.Println("verification passed")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "result.json file or artifact directory containing one")
+	cmd.Flags().StringVar(&policyFile, "policy", "", "verification policy file")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("policy")
+	return cmd
+}
+
+// verifyResult applies the policy, returning an exitCodeError for the
+// first violated class in severity order: forbidden changes, findings,
+// then size.
+func verifyResult(result *engine.DiffResult, pol verifyPolicy) error {
+	for _, class := range pol.Forbid {
+		violated := false
+		switch class {
+		case "build-error":
+			violated = result.HasBuildErrors()
+		case "namespace-deletion":
+			violated = len(analysis.NamespaceRemovals(result)) > 0
+		case "crd-removal":
+			violated = len(analysis.CRDRemovals(result)) > 0
+		case "production-deletion":
+			for _, c := range result.Components {
+				if c.Deleted && c.Path.Environment == "production" {
+					violated = true
+				}
+			}
+		default:
+			return fmt.Errorf("unknown forbidden class %q", class)
+		}
+		if violated {
+			return &exitCodeError{exitVerifyForbidden, fmt.Sprintf("forbidden change class: %s", class)}
+		}
+	}
+	if pol.FailOnSeverity != "" {
+		threshold := severityRank(pol.FailOnSeverity)
+		for _, f := range result.Findings {
+			if severityRank(f.Severity) >= threshold {
+				return &exitCodeError{exitVerifyFindings, fmt.Sprintf("finding at or above %s severity: [%s] %s", pol.FailOnSeverity, f.Category, f.Message)}
+			}
+		}
+	}
+	var totalLines, changed int
+	for _, c := range result.Components {
+		if c.Diff != "" || c.Error != "" {
+			changed++
+		}
+		totalLines += c.Added + c.Removed
+	}
+	if pol.MaxDiffLines > 0 && totalLines > pol.MaxDiffLines {
+		return &exitCodeError{exitVerifySize, fmt.Sprintf("diff spans %d lines, over the policy limit of %d", totalLines, pol.MaxDiffLines)}
+	}
+	if pol.MaxComponents > 0 && changed > pol.MaxComponents {
+		return &exitCodeError{exitVerifySize, fmt.Sprintf("%d components changed, over the policy limit of %d", changed, pol.MaxComponents)}
+	}
+	return nil
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 2
+	case "warning":
+		return 1
+	default:
+		return 0
+	}
+}